@@ -0,0 +1,108 @@
+// The areaview tool is an interactive viewer of the stitched layers of map
+// areas, for inspecting the output of dump_layers.
+//
+// Usage:
+//
+//	areaview [OPTION]... AREA
+//
+// Controls:
+//
+//	1-4          show BKG, NM, HGT or AS layer
+//	Tab          cycle shown layer
+//	O            cycle overlay layer blended on top
+//	[ ]          decrease or increase overlay opacity
+//	G            toggle chunk grid
+//	arrows/WASD  pan
+//	mouse drag   pan
+//	mouse wheel  zoom
+//	Home         fit map area to window
+//
+// The chunk grid shows the R%03d_C%03d chunk boundaries recorded by dump_layers
+// in the chunk layout file of the area (e.g. "_assets_/yenwood_chunks.json").
+// For areas stitched without chunk layout file, the -rows and -cols flags
+// approximate the chunk grid by dividing the area evenly.
+//
+// The viewer requires a display; builds using the headless build tag (i.e. go
+// build -tags headless) are not supported.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/mewspring/ren/pkg/assets"
+	"github.com/pkg/errors"
+)
+
+func main() {
+	if err := areaview(os.Args[1:]); err != nil {
+		log.Fatalf("%+v", err)
+	}
+}
+
+// areaview views the stitched layers of the map area specified by the given
+// command line arguments.
+func areaview(args []string) error {
+	var (
+		// Game assets directory.
+		assetsDir string
+		// Number of chunk rows and columns of areas without chunk layout file.
+		nrows, ncols int
+		// Screen size in pixels.
+		width, height int
+	)
+	fs := flag.NewFlagSet("areaview", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: areaview [OPTION]... AREA")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Flags:")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&assetsDir, "assets", assets.AssetsDir, "game assets directory")
+	fs.IntVar(&nrows, "rows", 0, "number of chunk rows of areas without chunk layout file")
+	fs.IntVar(&ncols, "cols", 0, "number of chunk columns of areas without chunk layout file")
+	fs.IntVar(&width, "width", 1280, "screen width in pixels")
+	fs.IntVar(&height, "height", 768, "screen height in pixels")
+	if err := fs.Parse(args); err != nil {
+		return errors.WithStack(err)
+	}
+	if fs.NArg() != 1 {
+		return errors.New("invalid number of arguments; usage: areaview [OPTION]... AREA")
+	}
+	areaName := fs.Arg(0)
+	assets.AssetsDir = assetsDir
+	area, err := assets.LoadArea(areaName)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	layout, err := loadChunkLayout(area, nrows, ncols)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	v := newViewer(area, layout, width, height)
+	if err := runViewer(v); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// loadChunkLayout loads the chunk layout of the given map area; or approximates
+// it by the given number of chunk rows and columns if the area has no chunk
+// layout file. A nil chunk layout is returned if neither is present; the
+// viewer then reports the chunk grid as unavailable.
+func loadChunkLayout(area *assets.Area, nrows, ncols int) (*assets.ChunkLayout, error) {
+	path := assets.ChunkLayoutPath(area.Name)
+	if _, err := os.Stat(path); err == nil {
+		layout, err := assets.LoadChunkLayout(area.Name)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return layout, nil
+	}
+	if nrows > 0 && ncols > 0 {
+		return assets.UniformChunkLayout(area.BackgroundLayer.Bounds(), nrows, ncols), nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,211 @@
+//go:build !headless
+// +build !headless
+
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+	"github.com/hajimehoshi/ebiten/inpututil"
+	"github.com/mewspring/ren/pkg/assets"
+	"github.com/mewspring/ren/pkg/render/ebitenrender"
+	"github.com/pkg/errors"
+)
+
+// Controls of the viewer.
+const (
+	// Pan speed of arrow keys, in screen pixels per tick.
+	panSpeed = 16
+	// Zoom factor per mouse wheel step.
+	zoomStep = 1.1
+	// Minimum width in screen pixels of chunks labelled by the chunk grid.
+	minLabelWidth = 80
+)
+
+// Colors of the viewer.
+var (
+	// Color of chunk grid lines.
+	gridColor = color.RGBA{R: 0xFF, G: 0xFF, A: 0xFF}
+	// Background color of text boxes.
+	textBackground = color.RGBA{A: 0xA0}
+)
+
+// Height in pixels of text lines of ebitenutil.DebugPrintAt.
+const lineHeight = 16
+
+// layerKeys maps from key to index of layer in layerKinds.
+var layerKeys = map[ebiten.Key]int{
+	ebiten.Key1: 0,
+	ebiten.Key2: 1,
+	ebiten.Key3: 2,
+	ebiten.Key4: 3,
+}
+
+// view is the Ebiten front end of a viewer.
+type view struct {
+	*viewer
+	// Source images uploaded to GPU.
+	texs *ebitenrender.TextureCache
+	// Specifies whether the map area is being dragged by the mouse.
+	dragging bool
+	// Last cursor position while dragging, in screen coordinates.
+	dragX, dragY int
+}
+
+// runViewer runs the given viewer interactively.
+func runViewer(v *viewer) error {
+	w := &view{
+		viewer: v,
+		texs:   ebitenrender.NewTextureCache(v.imgs),
+	}
+	title := "areaview - " + v.area.Name
+	if err := ebiten.Run(w.update, v.cam.Width, v.cam.Height, 1, title); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// update updates the viewer based on user input and draws the screen.
+func (w *view) update(screen *ebiten.Image) error {
+	w.handleInput()
+	if ebiten.IsDrawingSkipped() {
+		return nil
+	}
+	if err := w.draw(screen); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// handleInput handles the key presses and mouse input of the user.
+func (w *view) handleInput() {
+	for key, layer := range layerKeys {
+		if inpututil.IsKeyJustPressed(key) {
+			w.layer = layer
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+		w.layer = (w.layer + 1) % len(layerKinds)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyO) {
+		w.cycleOverlay()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyLeftBracket) {
+		w.setOpacity(w.opacity - opacityStep)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyRightBracket) {
+		w.setOpacity(w.opacity + opacityStep)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyG) && w.layout != nil {
+		w.grid = !w.grid
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyHome) {
+		w.fit()
+	}
+	// Pan by keyboard.
+	var dx, dy float64
+	if ebiten.IsKeyPressed(ebiten.KeyLeft) || ebiten.IsKeyPressed(ebiten.KeyA) {
+		dx -= panSpeed
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyRight) || ebiten.IsKeyPressed(ebiten.KeyD) {
+		dx += panSpeed
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyUp) || ebiten.IsKeyPressed(ebiten.KeyW) {
+		dy -= panSpeed
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyDown) || ebiten.IsKeyPressed(ebiten.KeyS) {
+		dy += panSpeed
+	}
+	if dx != 0 || dy != 0 {
+		w.cam.Pan(dx, dy)
+	}
+	// Pan by mouse drag.
+	cx, cy := ebiten.CursorPosition()
+	switch {
+	case inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft):
+		w.dragging = true
+	case !ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft):
+		w.dragging = false
+	case w.dragging:
+		w.cam.Pan(float64(w.dragX-cx), float64(w.dragY-cy))
+	}
+	w.dragX, w.dragY = cx, cy
+	// Zoom at cursor by mouse wheel.
+	if _, yoff := ebiten.Wheel(); yoff != 0 {
+		w.cam.ZoomAt(math.Pow(zoomStep, yoff), float64(cx), float64(cy))
+	}
+}
+
+// draw draws the shown layer, overlay layer, chunk grid and pixel readout onto
+// the screen.
+func (w *view) draw(screen *ebiten.Image) error {
+	t := &ebitenrender.EbitenTarget{
+		Screen: screen,
+		Texs:   w.texs,
+	}
+	w.base.Layer = layerKinds[w.layer]
+	if err := w.base.DrawBackground(t, w.world, w.cam); err != nil {
+		return errors.WithStack(err)
+	}
+	if w.overlay >= 0 {
+		w.top.Layer = layerKinds[w.overlay]
+		t.ColorM.Scale(1, 1, 1, w.opacity)
+		if err := w.top.DrawBackground(t, w.world, w.cam); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if w.grid {
+		w.drawGrid(screen)
+	}
+	cx, cy := ebiten.CursorPosition()
+	x, y := w.cam.ScreenToWorld(float64(cx), float64(cy))
+	lines := append([]string{w.status()}, w.readout(int(math.Floor(x)), int(math.Floor(y)))...)
+	drawText(screen, lines, 8, 8)
+	if err := w.texs.ReleaseUnused(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// drawGrid draws the chunk boundaries of the map area onto the screen, labelled
+// by chunk name.
+func (w *view) drawGrid(screen *ebiten.Image) {
+	view := w.cam.Viewport()
+	for row := 0; row < w.layout.NRows; row++ {
+		for col := 0; col < w.layout.NCols; col++ {
+			r := w.layout.ChunkRect(row, col)
+			if !r.Overlaps(view) {
+				continue
+			}
+			x0, y0 := w.cam.WorldToScreen(float64(r.Min.X), float64(r.Min.Y))
+			x1, y1 := w.cam.WorldToScreen(float64(r.Max.X), float64(r.Max.Y))
+			ebitenutil.DrawLine(screen, x0, y0, x1, y0, gridColor)
+			ebitenutil.DrawLine(screen, x0, y0, x0, y1, gridColor)
+			ebitenutil.DrawLine(screen, x1, y0, x1, y1, gridColor)
+			ebitenutil.DrawLine(screen, x0, y1, x1, y1, gridColor)
+			if x1-x0 >= minLabelWidth {
+				ebitenutil.DebugPrintAt(screen, assets.ChunkName(row, col), int(x0)+4, int(y0)+4)
+			}
+		}
+	}
+}
+
+// drawText draws the given lines of text onto the screen at (x, y), on a
+// translucent background.
+func drawText(screen *ebiten.Image, lines []string, x, y int) {
+	width := 0
+	for _, line := range lines {
+		if n := len(line); n > width {
+			width = n
+		}
+	}
+	// ebitenutil.DebugPrintAt uses a 6x16 pixel font.
+	r := image.Rect(x-4, y-4, x+width*6+4, y+len(lines)*lineHeight+4)
+	ebitenutil.DrawRect(screen, float64(r.Min.X), float64(r.Min.Y), float64(r.Dx()), float64(r.Dy()), textBackground)
+	ebitenutil.DebugPrintAt(screen, strings.Join(lines, "\n"), x, y)
+}
@@ -0,0 +1,11 @@
+//go:build headless
+// +build headless
+
+package main
+
+import "github.com/pkg/errors"
+
+// runViewer runs the given viewer interactively.
+func runViewer(v *viewer) error {
+	return errors.New("area viewer not supported by headless build")
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/mewspring/ren/pkg/assets"
+	"github.com/mewspring/ren/pkg/camera"
+	"github.com/mewspring/ren/pkg/depth"
+	"github.com/mewspring/ren/pkg/light"
+	"github.com/mewspring/ren/pkg/render"
+	"github.com/mewspring/ren/pkg/sim"
+)
+
+// layerKinds specifies the layers shown by the viewer, in cycle order.
+var layerKinds = []assets.LayerKind{
+	assets.LayerKindBackground,
+	assets.LayerKindNormal,
+	assets.LayerKindHeight,
+	assets.LayerKindAS,
+}
+
+// Viewer settings.
+const (
+	// Maximum zoom factor; screen pixels per background pixel.
+	maxZoom = 16
+	// Default opacity of overlay layers.
+	defaultOpacity = 0.5
+	// Opacity change per key press.
+	opacityStep = 0.1
+)
+
+// viewer is an interactive viewer of the stitched layers of a map area.
+type viewer struct {
+	// Map area.
+	area *assets.Area
+	// Chunk layout of the map area; or nil if unknown.
+	layout *assets.ChunkLayout
+	// Camera viewing the map area.
+	cam *camera.Camera
+	// World of the map area; without units.
+	world *sim.World
+	// Height map of the height layer; or nil if not present.
+	heights *depth.Map
+	// Source images, indexed by name.
+	imgs map[string]image.Image
+	// Renderers of the shown layer and the overlay layer.
+	base, top *render.Renderer
+	// Index of shown layer, in layerKinds.
+	layer int
+	// Index of overlay layer blended on top of the shown layer, in layerKinds;
+	// or -1 if none.
+	overlay int
+	// Opacity of overlay layer, in the range [0, 1].
+	opacity float64
+	// Specifies whether to show the chunk grid.
+	grid bool
+}
+
+// newViewer returns a new viewer of the given map area and chunk layout, with
+// the given screen size.
+func newViewer(area *assets.Area, layout *assets.ChunkLayout, width, height int) *viewer {
+	v := &viewer{
+		area:    area,
+		layout:  layout,
+		cam:     camera.New(width, height, area.BackgroundLayer.Bounds()),
+		world:   sim.NewWorld(area),
+		imgs:    render.SplitTiles(render.BackgroundImage, area.BackgroundLayer, render.TileSize),
+		overlay: -1,
+		opacity: defaultOpacity,
+		grid:    layout != nil,
+	}
+	if area.HeightLayer != nil {
+		v.heights = depth.New(area.HeightLayer, area.BackgroundLayer.Bounds())
+	}
+	v.base = render.NewRenderer(v.imgs)
+	v.top = render.NewRenderer(v.imgs)
+	v.cam.MaxZoom = maxZoom
+	v.fit()
+	return v
+}
+
+// fit zooms out to fit the entire map area within the viewport.
+func (v *viewer) fit() {
+	cam := v.cam
+	b := v.area.BackgroundLayer.Bounds()
+	zoom := math.Min(float64(cam.Width)/float64(b.Dx()), float64(cam.Height)/float64(b.Dy()))
+	zoom = math.Min(zoom, 1)
+	cam.MinZoom = zoom
+	cam.Zoom = zoom
+	cam.CenterOn(float64(b.Min.X+b.Max.X)/2, float64(b.Min.Y+b.Max.Y)/2)
+}
+
+// cycleOverlay selects the next overlay layer; or no overlay after the last
+// layer.
+func (v *viewer) cycleOverlay() {
+	v.overlay++
+	if v.overlay >= len(layerKinds) {
+		v.overlay = -1
+	}
+}
+
+// setOpacity sets the opacity of the overlay layer, clamped to [0, 1].
+func (v *viewer) setOpacity(opacity float64) {
+	v.opacity = math.Max(0, math.Min(1, opacity))
+}
+
+// status returns the status line of the viewer; the shown layers, zoom and
+// availability of the chunk grid.
+func (v *viewer) status() string {
+	s := fmt.Sprintf("%s  layer %v", v.area.Name, layerKinds[v.layer])
+	if v.overlay >= 0 {
+		s += fmt.Sprintf("  overlay %v (%.0f%%)", layerKinds[v.overlay], v.opacity*100)
+	}
+	s += fmt.Sprintf("  zoom %.3f", v.cam.Zoom)
+	if v.layout == nil {
+		s += "  chunk grid unavailable (use -rows and -cols)"
+	}
+	return s
+}
+
+// readout returns the pixel readout of all layers at the given position, in
+// pixels of the background layer.
+func (v *viewer) readout(x, y int) []string {
+	bb := v.area.BackgroundLayer.Bounds()
+	if !image.Pt(x, y).In(bb) {
+		return []string{fmt.Sprintf("pos (%d, %d)  outside of area", x, y)}
+	}
+	pos := fmt.Sprintf("pos (%d, %d)", x, y)
+	if v.layout != nil {
+		if row, col, ok := v.layout.ChunkAt(x, y); ok {
+			pos += fmt.Sprintf("  chunk %s", assets.ChunkName(row, col))
+		}
+	}
+	lines := []string{pos}
+	for _, kind := range layerKinds {
+		img := layerImage(v.area, kind)
+		if img == nil {
+			lines = append(lines, fmt.Sprintf("%-4v missing", kind))
+			continue
+		}
+		lx, ly := layerPos(img, bb, x, y)
+		c := color.NRGBAModel.Convert(img.At(lx, ly)).(color.NRGBA)
+		line := fmt.Sprintf("%-4v (%d, %d)  #%02X%02X%02X%02X", kind, lx, ly, c.R, c.G, c.B, c.A)
+		switch kind {
+		case assets.LayerKindNormal:
+			n := light.DecodeNormal(c)
+			line += fmt.Sprintf("  normal (%.2f, %.2f, %.2f)", n.X, n.Y, n.Z)
+		case assets.LayerKindHeight:
+			line += fmt.Sprintf("  height %.1f", v.heights.HeightAt(x, y))
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// layerImage returns the layer of the given kind of the map area; or nil if not
+// present.
+func layerImage(area *assets.Area, kind assets.LayerKind) image.Image {
+	switch kind {
+	case assets.LayerKindBackground:
+		return area.BackgroundLayer
+	case assets.LayerKindNormal:
+		return area.NormalLayer
+	case assets.LayerKindHeight:
+		return area.HeightLayer
+	case assets.LayerKindAS:
+		return area.ASLayer
+	}
+	return nil
+}
+
+// layerPos returns the position in the given layer corresponding to the given
+// position in the background layer with the given bounds. Layers may have a
+// lower resolution than the background layer (e.g. the half resolution normal
+// layer).
+func layerPos(layer image.Image, bb image.Rectangle, x, y int) (int, int) {
+	lb := layer.Bounds()
+	lx := lb.Min.X + (x-bb.Min.X)*lb.Dx()/bb.Dx()
+	ly := lb.Min.Y + (y-bb.Min.Y)*lb.Dy()/bb.Dy()
+	return lx, ly
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/mewkiz/pkg/imgutil"
 	"github.com/mewspring/ren/pkg/assets"
+	"github.com/mewspring/ren/pkg/minimap"
 	"github.com/pkg/errors"
 )
 
@@ -57,13 +58,18 @@ func (area *Area) Dump() error {
 	if err := os.MkdirAll(assets.AssetsDir, 0755); err != nil {
 		return errors.WithStack(err)
 	}
-	// TODO: add support for background small (thumbnails)?
 	// Output background layer.
 	backgroundPath := area.layerPath(assets.LayerKindBackground)
 	fmt.Printf("creating %q\n", backgroundPath)
 	if err := imgutil.WriteFile(backgroundPath, area.BackgroundLayer); err != nil {
 		return errors.WithStack(err)
 	}
+	// Output background (small) layer; a thumbnail of the background layer.
+	thumbPath := area.layerPath(assets.LayerKindBackgroundSmall)
+	fmt.Printf("creating %q\n", thumbPath)
+	if err := imgutil.WriteFile(thumbPath, minimap.Thumbnail(area.BackgroundLayer, minimap.ThumbSize)); err != nil {
+		return errors.WithStack(err)
+	}
 	// Output normal layer.
 	normalPath := area.layerPath(assets.LayerKindNormal)
 	fmt.Printf("creating %q\n", normalPath)
@@ -82,9 +88,33 @@ func (area *Area) Dump() error {
 	if err := imgutil.WriteFile(asPath, area.ASLayer); err != nil {
 		return errors.WithStack(err)
 	}
+	// Output chunk layout.
+	chunksPath := assets.ChunkLayoutPath(area.Name)
+	fmt.Printf("creating %q\n", chunksPath)
+	if err := area.chunkLayout().Write(chunksPath); err != nil {
+		return errors.WithStack(err)
+	}
 	return nil
 }
 
+// chunkLayout returns the layout of the background layer chunks of the given
+// area.
+func (area *Area) chunkLayout() *assets.ChunkLayout {
+	l := &assets.ChunkLayout{
+		NRows:      area.NRows,
+		NCols:      area.NCols,
+		ColWidths:  make([]int, area.NCols),
+		RowHeights: make([]int, area.NRows),
+	}
+	for col := 0; col < area.NCols; col++ {
+		l.ColWidths[col] = area.chunk(assets.LayerKindBackground, 0, col).Bounds().Dx()
+	}
+	for row := 0; row < area.NRows; row++ {
+		l.RowHeights[row] = area.chunk(assets.LayerKindBackground, row, 0).Bounds().Dy()
+	}
+	return l
+}
+
 // layerPath returns the full path to the specified layer asset of the given
 // area.
 func (area *Area) layerPath(kind assets.LayerKind) string {
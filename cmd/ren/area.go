@@ -0,0 +1,158 @@
+package main
+
+import (
+	"github.com/mewspring/ren/pkg/entity"
+	"github.com/mewspring/ren/pkg/scene"
+	"github.com/pkg/errors"
+)
+
+// areaState is the persistent state of a visited map area, kept while the party
+// is in other areas.
+type areaState struct {
+	// Specifies whether the unit of a scene spawn is dead, indexed by spawn
+	// index.
+	dead map[int]bool
+	// Explored cells of the fog of war (see minimap.Fog.Bitmap); or nil if
+	// unknown.
+	explored []byte
+}
+
+// saveState returns the persistent state of the level.
+func (l *level) saveState() *areaState {
+	st := &areaState{
+		dead:     make(map[int]bool),
+		explored: l.minimap.Fog.Bitmap(),
+	}
+	for id, i := range l.spawns {
+		if unit, ok := l.world.Units.ByID(id); ok && unit.Dead() {
+			st.dead[i] = true
+		}
+	}
+	return st
+}
+
+// restoreState restores the given persistent state of the level; monsters
+// killed on previous visits stay dead and explored regions stay explored.
+func (l *level) restoreState(st *areaState) {
+	if st.explored != nil {
+		l.restoreFog(st.explored)
+	}
+	for id, i := range l.spawns {
+		if !st.dead[i] {
+			continue
+		}
+		if unit, ok := l.world.Units.ByID(id); ok {
+			unit.SetDead()
+		}
+	}
+}
+
+// party returns the living units of the player faction, starting with the
+// player unit.
+func (l *level) party() []*entity.Unit {
+	var party []*entity.Unit
+	if player, ok := l.world.Player(); ok && !player.Dead() {
+		party = append(party, player)
+	}
+	for _, unit := range l.world.Units.Units {
+		if unit.ID == l.world.PlayerID || unit.Dead() || unit.Faction != entity.FactionPlayer {
+			continue
+		}
+		party = append(party, unit)
+	}
+	return party
+}
+
+// enter places the given party at the named entry point of the level, replacing
+// the units of the player faction spawned by its scene. The first unit of the
+// party becomes the player unit.
+func (l *level) enter(party []*entity.Unit, entryName string) error {
+	entry, ok := l.scene.EntryByName(entryName)
+	if !ok {
+		return errors.Errorf("unable to locate entry point %q of area %q", entryName, l.scene.Area)
+	}
+	dir, err := scene.ParseFacing(entry.Facing)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var spawned []int
+	for _, unit := range l.world.Units.Units {
+		if unit.Faction == entity.FactionPlayer {
+			spawned = append(spawned, unit.ID)
+		}
+	}
+	for _, id := range spawned {
+		l.world.Units.Remove(id)
+		delete(l.spawns, id)
+	}
+	l.world.PlayerID = 0
+	l.world.Selected = nil
+	l.world.Place(party, entry.X, entry.Y)
+	for i, unit := range party {
+		unit.Dir = dir
+		unit.Idle()
+		l.world.Units.Add(unit)
+		if i == 0 {
+			l.world.PlayerID = unit.ID
+		}
+		l.world.Selected = append(l.world.Selected, unit.ID)
+	}
+	// Arriving within an exit region does not trigger the exit.
+	l.inExit = true
+	l.cam.CenterOn(entry.X, entry.Y)
+	l.updateLighting()
+	return nil
+}
+
+// checkExit returns the exit region entered by the player unit since the last
+// check. The boolean return value indicates if an exit region was entered.
+func (l *level) checkExit() (scene.Exit, bool) {
+	player, ok := l.world.Player()
+	if !ok || player.Dead() {
+		return scene.Exit{}, false
+	}
+	exit, ok := l.exitAt(player.X, player.Y)
+	if !ok {
+		l.inExit = false
+		return scene.Exit{}, false
+	}
+	if l.inExit {
+		return scene.Exit{}, false
+	}
+	l.inExit = true
+	return exit, true
+}
+
+// exitAt returns the exit region containing the given position, in area pixel
+// coordinates. The boolean return value indicates if such an exit region
+// exists.
+func (l *level) exitAt(x, y float64) (scene.Exit, bool) {
+	for _, exit := range l.exits {
+		if exit.Rect.Contains(x, y) {
+			return exit, true
+		}
+	}
+	return scene.Exit{}, false
+}
+
+// changeArea leaves the given level through the given exit, storing the state
+// of the level in areas, and returns the level of the destination area with the
+// party of the player placed at its entry point.
+func changeArea(old *level, areas map[string]*areaState, exit scene.Exit) (*level, error) {
+	areas[old.scene.Area] = old.saveState()
+	party := old.party()
+	l, err := loadLevel(exit.Dest)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if st, ok := areas[exit.Dest]; ok {
+		l.restoreState(st)
+	}
+	if err := l.enter(party, exit.Entry); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// Seed the world of the destination area from the world of the old area,
+	// to keep recorded play sessions reproducible.
+	l.world.Reseed(old.world.Rand.Int63())
+	return l, nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+
+	"github.com/mewspring/ren/pkg/assets"
+	"github.com/pkg/errors"
+)
+
+// defaultConfigPath specifies the path to the configuration file, unless
+// overridden by the -config flag.
+const defaultConfigPath = "ren.json"
+
+// config is the configuration of interactive play; read from the configuration
+// file and overridden by command line flags.
+type config struct {
+	// Logical screen width and height in pixels.
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// Scale factor of the window; the window size is the screen size scaled by
+	// the scale factor.
+	Scale float64 `json:"scale"`
+	// Window title.
+	Title string `json:"title"`
+	// Specifies whether to run in fullscreen mode.
+	Fullscreen bool `json:"fullscreen"`
+	// Specifies whether to synchronize frames with the refresh rate of the
+	// display.
+	VSync bool `json:"vsync"`
+	// Name of starting map area (e.g. "AR_0705_Gilded_Vale").
+	Area string `json:"area"`
+	// Path to scene file of starting map area; overrides Area if present.
+	Scene string `json:"scene,omitempty"`
+	// Game assets directory.
+	AssetsDir string `json:"assets_dir"`
+	// Log level ("error", "warn", "info" or "debug").
+	LogLevel string `json:"log_level"`
+}
+
+// defaultConfig returns the default configuration.
+func defaultConfig() *config {
+	return &config{
+		Width:     screenWidth,
+		Height:    screenHeight,
+		Scale:     1.0,
+		Title:     "ren",
+		VSync:     true,
+		Area:      "yenwood",
+		AssetsDir: "_assets_",
+		LogLevel:  "info",
+	}
+}
+
+// parseConfig returns the configuration of the configuration file, overridden
+// by the given command line flags. Options not present in the configuration
+// file keep their default value. The flags of the configuration are added to
+// the given flag set, which may hold additional flags of subcommands.
+func parseConfig(fs *flag.FlagSet, args []string) (*config, error) {
+	cfg := defaultConfig()
+	var (
+		// Path to configuration file.
+		configPath string
+	)
+	fs.StringVar(&configPath, "config", defaultConfigPath, "path to configuration file")
+	fs.IntVar(&cfg.Width, "width", cfg.Width, "screen width in pixels")
+	fs.IntVar(&cfg.Height, "height", cfg.Height, "screen height in pixels")
+	fs.Float64Var(&cfg.Scale, "scale", cfg.Scale, "window scale factor")
+	fs.StringVar(&cfg.Title, "title", cfg.Title, "window title")
+	fs.BoolVar(&cfg.Fullscreen, "fullscreen", cfg.Fullscreen, "run in fullscreen mode")
+	fs.BoolVar(&cfg.VSync, "vsync", cfg.VSync, "synchronize frames with display refresh rate")
+	fs.StringVar(&cfg.Area, "area", cfg.Area, "starting map area")
+	fs.StringVar(&cfg.Scene, "scene", cfg.Scene, "scene file of starting map area; overrides -area")
+	fs.StringVar(&cfg.AssetsDir, "assets", cfg.AssetsDir, "game assets directory")
+	fs.StringVar(&cfg.LogLevel, "log", cfg.LogLevel, "log level (error, warn, info or debug)")
+	// Parse command line flags twice; first to locate the configuration file and
+	// then to override the options of the configuration file.
+	if err := fs.Parse(args); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// The default configuration file is optional, but configuration files
+	// specified by the -config flag are not.
+	optional := true
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "config" {
+			optional = false
+		}
+	})
+	if err := cfg.load(configPath, optional); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, errors.Errorf("invalid screen size %dx%d", cfg.Width, cfg.Height)
+	}
+	if cfg.Scale <= 0 {
+		return nil, errors.Errorf("invalid window scale factor %v", cfg.Scale)
+	}
+	return cfg, nil
+}
+
+// applyConfig applies the global options of the given configuration; the
+// screen size, assets directory and log level.
+func applyConfig(cfg *config) error {
+	level, err := parseLogLevel(cfg.LogLevel)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	curLogLevel = level
+	screenWidth, screenHeight = cfg.Width, cfg.Height
+	assets.AssetsDir = cfg.AssetsDir
+	return nil
+}
+
+// load loads the options present in the given JSON configuration file. A
+// missing configuration file is not an error if optional is set.
+func (cfg *config) load(path string, optional bool) error {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		if optional && os.IsNotExist(err) {
+			return nil
+		}
+		return errors.WithStack(err)
+	}
+	if err := json.Unmarshal(buf, cfg); err != nil {
+		return errors.Wrapf(err, "unable to parse configuration file %q", path)
+	}
+	return nil
+}
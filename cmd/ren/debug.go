@@ -0,0 +1,114 @@
+//go:build !headless
+// +build !headless
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+	"github.com/mewspring/ren/pkg/ai"
+	"github.com/mewspring/ren/pkg/entity"
+	"github.com/mewspring/ren/pkg/input"
+	"github.com/mewspring/ren/pkg/render"
+	"github.com/mewspring/ren/pkg/selection"
+)
+
+// debugLayers specifies the layers drawn as background by the debug overlay, in
+// cycle order (see showLayer).
+var debugLayers = []string{"background", "normal", "height", "as", render.WalkabilityImage}
+
+// debugOverlay is a debug overlay showing frame timings, the camera and the
+// entity under the cursor.
+type debugOverlay struct {
+	// Specifies whether the debug overlay is shown.
+	visible bool
+	// Index of layer drawn as background, in debugLayers.
+	layer int
+	// Duration of last update.
+	updateDur time.Duration
+	// Duration of last draw.
+	drawDur time.Duration
+}
+
+// debugBackground is the background color of the debug overlay.
+var debugBackground = color.RGBA{A: 0xA0}
+
+// Debug overlay layout, in screen pixels.
+const (
+	// Position of the debug overlay.
+	debugX, debugY = 8, 8
+	// Width of the debug overlay.
+	debugWidth = 300
+	// Line height of debug text.
+	debugLineHeight = 16
+)
+
+// updateDebug updates the debug overlay based on user input. The toggle debug
+// action (F3 by default) shows or hides the overlay and the debug layer action
+// (F4 by default) cycles the layer drawn as background.
+func (game *Game) updateDebug() {
+	if game.input.JustPressed(input.ActionToggleDebug) {
+		game.debug.visible = !game.debug.visible
+	}
+	if game.input.JustPressed(input.ActionDebugLayer) {
+		game.debug.layer = (game.debug.layer + 1) % len(debugLayers)
+	}
+}
+
+// drawDebug draws the debug overlay to screen.
+func (game *Game) drawDebug(screen *ebiten.Image) {
+	lines := game.debugLines()
+	h := len(lines)*debugLineHeight + 8
+	ebitenutil.DrawRect(screen, debugX-4, debugY-4, debugWidth, float64(h), debugBackground)
+	ebitenutil.DebugPrintAt(screen, strings.Join(lines, "\n"), debugX, debugY)
+}
+
+// debugLines returns the lines of text of the debug overlay.
+func (game *Game) debugLines() []string {
+	l := game.level
+	w := l.world
+	cam := l.cam
+	cx, cy := game.input.CursorX, game.input.CursorY
+	wx, wy := cam.ScreenToWorld(float64(cx), float64(cy))
+	walkable := w.Nav == nil || w.Nav.IsWalkable(wx, wy)
+	lines := []string{
+		fmt.Sprintf("FPS %.1f  TPS %.1f", ebiten.CurrentFPS(), ebiten.CurrentTPS()),
+		fmt.Sprintf("update %v  draw %v", game.debug.updateDur.Round(time.Microsecond), game.debug.drawDur.Round(time.Microsecond)),
+		fmt.Sprintf("area %s  tick %d", l.scene.Area, w.Tick),
+		fmt.Sprintf("camera (%.0f, %.0f)  zoom %.2f", cam.X, cam.Y, cam.Zoom),
+		fmt.Sprintf("cursor (%.0f, %.0f)  walkable %v", wx, wy, walkable),
+		fmt.Sprintf("layer %s  (F4 to cycle)", debugLayers[game.debug.layer]),
+		fmt.Sprintf("units %d  projectiles %d", len(w.Units.Units), len(w.Projectiles)),
+	}
+	if unit, ok := selection.Pick(w.Units.Units, l.imgs, wx, wy); ok {
+		lines = append(lines, "")
+		lines = append(lines, game.unitLines(unit)...)
+	}
+	return lines
+}
+
+// unitLines returns the lines of text of the debug overlay describing the given
+// unit.
+func (game *Game) unitLines(unit *entity.Unit) []string {
+	frame := "-"
+	if a, ok := unit.Anim.Anim(); ok {
+		frame = fmt.Sprintf("%d/%d", a.CurFrame, a.NFrames)
+	}
+	lines := []string{
+		fmt.Sprintf("unit %d  %s  (%v)", unit.ID, unit.Type.Name, unit.Faction),
+		fmt.Sprintf("anim %v  frame %s  facing %v", unit.Anim.State, frame, unit.Dir),
+		fmt.Sprintf("pos (%.0f, %.0f)  hp %d/%d", unit.X, unit.Y, unit.HP, unit.Stats.MaxHP),
+		fmt.Sprintf("path %d  target %d", len(unit.Path), unit.Target),
+	}
+	for _, c := range game.level.world.Controllers {
+		if b, ok := c.(*ai.Brain); ok && b.UnitID == unit.ID {
+			lines = append(lines, fmt.Sprintf("ai %v", b.State))
+		}
+	}
+	return lines
+}
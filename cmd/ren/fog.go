@@ -0,0 +1,35 @@
+package main
+
+import "math"
+
+// sightRadius specifies the distance in pixels within which units of the party
+// of the player see; explored regions are revealed on the minimap and hostile
+// units are shown on the minimap while in sight.
+const sightRadius = 400
+
+// revealFog explores the fog of war of the level within sight of the party of
+// the player.
+func (l *level) revealFog() {
+	for _, unit := range l.party() {
+		l.minimap.Fog.Reveal(unit.X, unit.Y, sightRadius)
+	}
+}
+
+// restoreFog restores the explored cells of the fog of war of the level from
+// the given bitmap. Invalid bitmaps (e.g. of map areas changed since) are
+// ignored.
+func (l *level) restoreFog(explored []byte) {
+	if err := l.minimap.Fog.SetBitmap(explored); err != nil {
+		warnf("unable to restore fog of war of area %q; %v", l.scene.Area, err)
+	}
+}
+
+// inSight reports whether (x, y) is within sight of the party of the player.
+func (l *level) inSight(x, y float64) bool {
+	for _, unit := range l.party() {
+		if math.Hypot(unit.X-x, unit.Y-y) <= sightRadius {
+			return true
+		}
+	}
+	return false
+}
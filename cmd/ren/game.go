@@ -0,0 +1,491 @@
+//go:build !headless
+// +build !headless
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+	"github.com/mewspring/ren/pkg/entity"
+	"github.com/mewspring/ren/pkg/input"
+	"github.com/mewspring/ren/pkg/render/ebitenrender"
+	"github.com/mewspring/ren/pkg/replay"
+	"github.com/mewspring/ren/pkg/save"
+	"github.com/mewspring/ren/pkg/scene"
+	"github.com/mewspring/ren/pkg/selection"
+	"github.com/mewspring/ren/pkg/sim"
+	"github.com/pkg/errors"
+)
+
+// bindingsPath specifies the path to the input bindings file; default bindings
+// are used for actions not present in the file.
+const bindingsPath = "bindings.json"
+
+// runGame runs the game interactively, using the given configuration.
+func runGame(cfg *config) error {
+	bindings, err := input.Load(bindingsPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	game := &Game{
+		cfg:   cfg,
+		src:   newEbitenSource(),
+		input: input.NewState(bindings),
+	}
+	// Load game assets before opening the window; assets are decoded on the CPU
+	// and uploaded to GPU on first use.
+	if err := game.loadAssets(); err != nil {
+		return errors.WithStack(err)
+	}
+	ebiten.SetMaxTPS(sim.TPS)
+	ebiten.SetFullscreen(cfg.Fullscreen)
+	ebiten.SetVsyncEnabled(cfg.VSync)
+	ebiten.SetWindowSize(int(float64(screenWidth)*cfg.Scale), int(float64(screenHeight)*cfg.Scale))
+	ebiten.SetWindowTitle(cfg.Title)
+	if err := ebiten.RunGame(game); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// Game holds game state.
+//
+// Game implements the ebiten.Game interface; Update advances the simulation by
+// one fixed time step (see sim.TPS) and Draw renders the current state of the
+// simulation, at the frame rate of the display.
+type Game struct {
+	// Configuration of interactive play.
+	cfg *config
+	// Current level.
+	level *level
+	// Persistent state of visited map areas, indexed by area name.
+	areas map[string]*areaState
+	// Screen fade of current area transition; or nil if none.
+	fade *fade
+	// Source of physical input.
+	src input.Source
+	// Input state of named actions.
+	input *input.State
+	// Recorder of current play session; or nil if not recording.
+	rec *replay.Recorder
+	// Source images uploaded to GPU.
+	texs *ebitenrender.TextureCache
+	// Specifies whether the camera follows the player unit.
+	follow bool
+	// Specifies whether the left mouse button is held (e.g. drag selection).
+	dragging bool
+	// Start position of drag selection, in screen coordinates.
+	dragX, dragY int
+	// Specifies whether the left mouse button is held on the minimap.
+	minimapDragging bool
+	// Debug overlay.
+	debug debugOverlay
+	// Error encountered during last draw.
+	drawErr error
+}
+
+// Update updates the game state by one simulation tick. Errors encountered
+// during the last draw are reported by the following update, as Draw cannot
+// return errors.
+func (game *Game) Update(screen *ebiten.Image) error {
+	start := time.Now()
+	defer func() {
+		game.debug.updateDur = time.Since(start)
+	}()
+	if game.drawErr != nil {
+		return errors.WithStack(game.drawErr)
+	}
+	game.input.Update(game.src)
+	game.updateDebug()
+	if game.fade != nil {
+		// The world is frozen during area transitions.
+		if err := game.updateFade(); err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	}
+	if game.input.JustPressed(input.ActionQuicksave) {
+		if err := game.saveGame(quicksaveSlot); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if game.input.JustPressed(input.ActionQuickload) {
+		if err := game.loadGame(quicksaveSlot); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if game.input.JustPressed(input.ActionPause) {
+		game.level.world.Paused = !game.level.world.Paused
+	}
+	if game.input.JustPressed(input.ActionRecord) {
+		if err := game.toggleRecording(); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	// The world is updated while paused, to accept orders; the camera and
+	// renderer remain live.
+	in := game.readInput()
+	game.level.world.Update(in)
+	game.level.revealFog()
+	if game.rec != nil {
+		tick := replay.Tick{
+			Paused:   game.level.world.Paused,
+			Input:    in,
+			Checksum: game.level.world.Checksum(),
+		}
+		if err := game.rec.Record(tick); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	game.updateMinimap()
+	game.updateCamera()
+	if game.input.JustPressed(input.ActionToggleLighting) {
+		game.level.renderer.Lighting = !game.level.renderer.Lighting
+	}
+	if game.input.JustPressed(input.ActionToggleOcclusion) {
+		game.level.renderer.Occlusion = !game.level.renderer.Occlusion
+	}
+	game.level.updateLighting()
+	if exit, ok := game.level.checkExit(); ok {
+		game.fade = &fade{exit: exit}
+	}
+	return nil
+}
+
+// saveGame saves the game to the given save slot.
+func (game *Game) saveGame(slot string) error {
+	path := save.SlotPath(saveDir, slot)
+	infof("saving %q", path)
+	if err := save.Write(path, snapshot(game.level, game.areas)); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// loadGame loads the game of the given save slot. Loading an empty save slot
+// is a no-op.
+func (game *Game) loadGame(slot string) error {
+	path := save.SlotPath(saveDir, slot)
+	s, err := save.Read(path)
+	if err != nil {
+		if os.IsNotExist(errors.Cause(err)) {
+			warnf("save slot %q is empty", slot)
+			return nil
+		}
+		return errors.WithStack(err)
+	}
+	// Recordings start from a known game state; loading a save game ends the
+	// current recording.
+	if game.rec != nil {
+		if err := game.toggleRecording(); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	infof("loading %q", path)
+	l, areas, err := restore(s)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := game.texs.ReleaseAll(); err != nil {
+		return errors.WithStack(err)
+	}
+	game.level = l
+	game.areas = areas
+	game.texs = ebitenrender.NewTextureCache(l.imgs)
+	game.dragging = false
+	game.minimapDragging = false
+	game.follow = false
+	return nil
+}
+
+// toggleRecording starts or stops recording of the play session. Recordings
+// start from a snapshot of the current game state, with the world reseeded.
+func (game *Game) toggleRecording() error {
+	if game.rec != nil {
+		infof("recording stopped")
+		if err := game.rec.Close(); err != nil {
+			return errors.WithStack(err)
+		}
+		game.rec = nil
+		return nil
+	}
+	seed := time.Now().UnixNano()
+	game.level.world.Reseed(seed)
+	name := fmt.Sprintf("%s.jsonl", time.Now().Format("20060102_150405"))
+	path := filepath.Join(recordingsDir, name)
+	infof("recording %q", path)
+	rec, err := replay.Create(path, seed, snapshot(game.level, game.areas))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	game.rec = rec
+	return nil
+}
+
+// fadeTicks specifies the number of simulation ticks of each phase (out and in)
+// of screen fades.
+const fadeTicks = sim.TPS / 2
+
+// fade is a screen fade of an area transition; fading out of the current area,
+// changing area and fading in to the destination area.
+type fade struct {
+	// Exit region leading to the destination area.
+	exit scene.Exit
+	// Number of ticks elapsed of current phase.
+	tick int
+	// Specifies whether the fade is in its fade in phase.
+	in bool
+}
+
+// updateFade advances the screen fade of the current area transition by one
+// tick, changing area at the end of the fade out phase. The transition is
+// cancelled if the destination area fails to load.
+func (game *Game) updateFade() error {
+	f := game.fade
+	f.tick++
+	if f.tick < fadeTicks {
+		return nil
+	}
+	if f.in {
+		game.fade = nil
+		return nil
+	}
+	l, err := changeArea(game.level, game.areas, f.exit)
+	if err != nil {
+		warnf("unable to change area through exit %q; %v", f.exit.Name, err)
+		game.fade = nil
+		return nil
+	}
+	// Unload previous area.
+	if err := game.texs.ReleaseAll(); err != nil {
+		return errors.WithStack(err)
+	}
+	game.level = l
+	game.texs = ebitenrender.NewTextureCache(l.imgs)
+	game.dragging = false
+	game.minimapDragging = false
+	f.tick = 0
+	f.in = true
+	return nil
+}
+
+// opacity returns the opacity of the black screen overlay of the fade, in the
+// range [0, 1].
+func (f *fade) opacity() float64 {
+	t := float64(f.tick) / fadeTicks
+	if f.in {
+		return 1 - t
+	}
+	return t
+}
+
+// Draw renders the current game state to screen.
+func (game *Game) Draw(screen *ebiten.Image) {
+	start := time.Now()
+	t := &ebitenrender.EbitenTarget{Screen: screen, Texs: game.texs}
+	if err := showLayer(game.level.renderer, debugLayers[game.debug.layer]); err != nil {
+		game.drawErr = err
+	}
+	if err := game.level.renderer.DrawWorld(t, game.level.world, game.level.cam); err != nil {
+		game.drawErr = err
+	}
+	if err := game.texs.ReleaseUnused(); err != nil {
+		game.drawErr = err
+	}
+	if game.dragging {
+		game.drawDragRect(screen)
+	}
+	if err := game.drawMinimap(t); err != nil {
+		game.drawErr = err
+	}
+	if game.fade != nil {
+		a := uint8(255 * game.fade.opacity())
+		ebitenutil.DrawRect(screen, 0, 0, float64(screenWidth), float64(screenHeight), color.RGBA{A: a})
+	}
+	if game.level.world.Paused {
+		ebitenutil.DebugPrintAt(screen, "PAUSED", screenWidth/2-18, 16)
+	}
+	if game.debug.visible {
+		game.drawDebug(screen)
+	}
+	game.debug.drawDur = time.Since(start)
+}
+
+// dragRectColor is the color of drag selection rectangles.
+var dragRectColor = color.RGBA{R: 0x30, G: 0xD0, B: 0x30, A: 0xFF}
+
+// drawDragRect draws the outline of the current drag selection rectangle to
+// screen.
+func (game *Game) drawDragRect(screen *ebiten.Image) {
+	cx, cy := game.input.CursorX, game.input.CursorY
+	x0, y0 := float64(game.dragX), float64(game.dragY)
+	x1, y1 := float64(cx), float64(cy)
+	ebitenutil.DrawLine(screen, x0, y0, x1, y0, dragRectColor)
+	ebitenutil.DrawLine(screen, x1, y0, x1, y1, dragRectColor)
+	ebitenutil.DrawLine(screen, x1, y1, x0, y1, dragRectColor)
+	ebitenutil.DrawLine(screen, x0, y1, x0, y0, dragRectColor)
+}
+
+// Layout returns the logical screen size of the game, given the outside size of
+// the window.
+func (game *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return screenWidth, screenHeight
+}
+
+// readInput reads the player input of the current simulation tick.
+func (game *Game) readInput() sim.Input {
+	var in sim.Input
+	if game.input.Pressed(input.ActionMoveUp) {
+		in.MoveY += -1
+	}
+	if game.input.Pressed(input.ActionMoveDown) {
+		in.MoveY += +1
+	}
+	if game.input.Pressed(input.ActionMoveLeft) {
+		in.MoveX += -1
+	}
+	if game.input.Pressed(input.ActionMoveRight) {
+		in.MoveX += +1
+	}
+	cx, cy := game.input.CursorX, game.input.CursorY
+	if game.input.Pressed(input.ActionAttack) {
+		// Attack target at mouse cursor.
+		in.Attack = true
+		in.TargetX, in.TargetY = game.level.cam.ScreenToWorld(float64(cx), float64(cy))
+	}
+	if game.input.Pressed(input.ActionShoot) {
+		// Fire ranged attack towards mouse cursor.
+		in.Shoot = true
+		in.TargetX, in.TargetY = game.level.cam.ScreenToWorld(float64(cx), float64(cy))
+	}
+	if game.input.JustPressed(input.ActionMoveOrder) {
+		// Attack hostile unit at mouse cursor, or move to destination at mouse
+		// cursor; either on screen or on the minimap.
+		wx, wy := game.level.cam.ScreenToWorld(float64(cx), float64(cy))
+		units := game.level.world.Units.Units
+		if game.onMinimap(cx, cy) {
+			in.MoveTo = true
+			in.DestX, in.DestY = game.level.minimap.ToWorld(game.minimapRect(), float64(cx), float64(cy))
+		} else if unit, ok := selection.Pick(units, game.level.imgs, wx, wy); ok && !unit.Dead() && unit.Faction != entity.FactionPlayer {
+			in.AttackID = unit.ID
+		} else {
+			in.MoveTo = true
+			in.DestX, in.DestY = wx, wy
+		}
+	}
+	// Select units by click or drag selection; clicks on the minimap move the
+	// camera instead (see updateMinimap).
+	if game.input.JustPressed(input.ActionSelect) && !game.onMinimap(cx, cy) {
+		game.dragging = true
+		game.dragX, game.dragY = cx, cy
+	}
+	if game.dragging && game.input.JustReleased(input.ActionSelect) {
+		game.dragging = false
+		in.Select = game.selectUnits(game.dragX, game.dragY, cx, cy)
+	}
+	return in
+}
+
+// minDrag specifies the minimum distance in screen pixels of drag selections;
+// shorter drags are clicks.
+const minDrag = 4
+
+// selectUnits returns the selection change of a click or drag selection from
+// (x0, y0) to (x1, y1) in screen coordinates. A click selects the unit under
+// the cursor, and a drag selects the units within the drag rectangle; only
+// living units of the player faction are selected. Holding the add to selection
+// action (shift by default) adds to the current selection.
+func (game *Game) selectUnits(x0, y0, x1, y1 int) *sim.Select {
+	cam := game.level.cam
+	units := selection.Selectable(game.level.world.Units.Units)
+	sel := &sim.Select{
+		Add: game.input.Pressed(input.ActionAddSelect),
+	}
+	wx1, wy1 := cam.ScreenToWorld(float64(x1), float64(y1))
+	if abs(x1-x0) < minDrag && abs(y1-y0) < minDrag {
+		if unit, ok := selection.Pick(units, game.level.imgs, wx1, wy1); ok {
+			sel.IDs = []int{unit.ID}
+		}
+		return sel
+	}
+	wx0, wy0 := cam.ScreenToWorld(float64(x0), float64(y0))
+	sel.IDs = selection.InRect(units, wx0, wy0, wx1, wy1)
+	return sel
+}
+
+// abs returns the absolute value of x.
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// Camera controls.
+const (
+	// Camera pan speed in screen pixels per second.
+	panSpeed = 800
+	// Width of edge scrolling margin in screen pixels.
+	edgeMargin = 8
+	// Zoom factor per mouse wheel step.
+	zoomStep = 1.1
+)
+
+// updateCamera pans, zooms and moves the camera based on user input, by one
+// simulation tick. The pan actions (WASD by default) and the screen edges pan
+// the camera, the mouse wheel zooms and the follow action (F by default)
+// toggles following of the player unit. Panning stops following.
+func (game *Game) updateCamera() {
+	cam := game.level.cam
+	var dx, dy float64
+	if game.input.Pressed(input.ActionPanUp) {
+		dy += -1
+	}
+	if game.input.Pressed(input.ActionPanDown) {
+		dy += +1
+	}
+	if game.input.Pressed(input.ActionPanLeft) {
+		dx += -1
+	}
+	if game.input.Pressed(input.ActionPanRight) {
+		dx += +1
+	}
+	cx, cy := game.input.CursorX, game.input.CursorY
+	ex, ey := cam.EdgeScroll(cx, cy, edgeMargin)
+	dx += ex
+	dy += ey
+	if dx != 0 || dy != 0 {
+		game.follow = false
+		step := panSpeed * sim.TickDur.Seconds()
+		cam.Pan(dx*step, dy*step)
+	}
+	if wy := game.input.WheelY; wy != 0 {
+		cam.ZoomAt(math.Pow(zoomStep, wy), float64(cx), float64(cy))
+	}
+	if game.input.JustPressed(input.ActionFollow) {
+		game.follow = !game.follow
+	}
+	if game.follow {
+		game.level.followPlayer()
+	}
+}
+
+// loadAssets loads game assets.
+func (game *Game) loadAssets() error {
+	l, err := loadLevelScene(game.cfg.Area, game.cfg.Scene)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	game.level = l
+	game.areas = make(map[string]*areaState)
+	game.follow = true
+	game.texs = ebitenrender.NewTextureCache(l.imgs)
+	return nil
+}
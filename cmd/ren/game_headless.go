@@ -0,0 +1,11 @@
+//go:build headless
+// +build headless
+
+package main
+
+import "github.com/pkg/errors"
+
+// runGame runs the game interactively, using the given configuration.
+func runGame(cfg *config) error {
+	return errors.New("interactive mode not supported by headless build; use \"ren render\" or \"ren replay\"")
+}
@@ -0,0 +1,68 @@
+//go:build !headless
+// +build !headless
+
+package main
+
+import (
+	"strconv"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/mewspring/ren/pkg/input"
+)
+
+// ebitenSource is a source of physical input read from Ebiten.
+type ebitenSource struct {
+	// Keys, indexed by key name.
+	keys map[string]ebiten.Key
+}
+
+// newEbitenSource returns a new source of physical input read from Ebiten.
+func newEbitenSource() *ebitenSource {
+	keys := make(map[string]ebiten.Key)
+	for k := ebiten.Key(0); k <= ebiten.KeyMax; k++ {
+		keys[k.String()] = k
+	}
+	return &ebitenSource{keys: keys}
+}
+
+// mouseButtons maps from mouse button name to Ebiten mouse button.
+var mouseButtons = map[string]ebiten.MouseButton{
+	"Left":   ebiten.MouseButtonLeft,
+	"Right":  ebiten.MouseButtonRight,
+	"Middle": ebiten.MouseButtonMiddle,
+}
+
+// Pressed reports whether the given physical input is pressed. Gamepad
+// bindings match the button of any connected gamepad.
+func (src *ebitenSource) Pressed(b input.Binding) bool {
+	switch b.Device {
+	case input.DeviceKey:
+		k, ok := src.keys[b.Name]
+		return ok && ebiten.IsKeyPressed(k)
+	case input.DeviceMouse:
+		button, ok := mouseButtons[b.Name]
+		return ok && ebiten.IsMouseButtonPressed(button)
+	case input.DeviceGamepad:
+		n, err := strconv.Atoi(b.Name)
+		if err != nil || n < 0 || n > int(ebiten.GamepadButtonMax) {
+			return false
+		}
+		for _, id := range ebiten.GamepadIDs() {
+			if ebiten.IsGamepadButtonPressed(id, ebiten.GamepadButton(n)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CursorPosition returns the position of the mouse cursor in screen
+// coordinates.
+func (src *ebitenSource) CursorPosition() (x, y int) {
+	return ebiten.CursorPosition()
+}
+
+// Wheel returns the mouse wheel offset since last tick.
+func (src *ebitenSource) Wheel() (xoff, yoff float64) {
+	return ebiten.Wheel()
+}
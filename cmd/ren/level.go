@@ -0,0 +1,302 @@
+package main
+
+import (
+	"image"
+
+	"github.com/mewkiz/pkg/imgutil"
+	"github.com/mewspring/ren/pkg/ai"
+	"github.com/mewspring/ren/pkg/assets"
+	"github.com/mewspring/ren/pkg/camera"
+	"github.com/mewspring/ren/pkg/entity"
+	"github.com/mewspring/ren/pkg/light"
+	"github.com/mewspring/ren/pkg/minimap"
+	"github.com/mewspring/ren/pkg/nav"
+	"github.com/mewspring/ren/pkg/render"
+	"github.com/mewspring/ren/pkg/scene"
+	"github.com/mewspring/ren/pkg/sim"
+	"github.com/pkg/errors"
+)
+
+// level is a loaded map area; its simulated world, camera and the source
+// images used to render it.
+type level struct {
+	// Scene of the map area.
+	scene *scene.Scene
+	// Path to scene file of the map area; or empty if the scene was located by
+	// area name.
+	scenePath string
+	// Simulated game world.
+	world *sim.World
+	// Camera viewing the world.
+	cam *camera.Camera
+	// Renderer of the world.
+	renderer *render.Renderer
+	// Minimap of the map area.
+	minimap *minimap.Minimap
+	// Source images, indexed by name (see render.Op).
+	imgs map[string]image.Image
+	// Unit types, indexed by unit type name.
+	types map[string]*entity.Type
+	// Index of the torch of the player unit among the point lights of the world.
+	torch int
+	// Index of scene spawn of units, indexed by unit ID.
+	spawns map[int]int
+	// Exit regions of the scene leading to existing entry points of their
+	// destination areas.
+	exits []scene.Exit
+	// Specifies whether the player unit is within an exit region; exits trigger
+	// on entering the exit region.
+	inExit bool
+}
+
+// loadLevel loads the scene of the given map area; or the default scene if the
+// area has no scene file.
+func loadLevel(areaName string) (*level, error) {
+	s, ok, err := scene.LoadArea(areaName)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if !ok {
+		s = defaultScene(areaName)
+	}
+	return loadScene(s)
+}
+
+// loadSceneFile loads the scene of the given scene file.
+func loadSceneFile(path string) (*level, error) {
+	s, err := scene.Load(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	l, err := loadScene(s)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	l.scenePath = path
+	return l, nil
+}
+
+// loadLevelScene loads the scene of the given scene file if present; or else
+// the scene of the given map area (see loadLevel).
+func loadLevelScene(areaName, scenePath string) (*level, error) {
+	if len(scenePath) > 0 {
+		return loadSceneFile(scenePath)
+	}
+	return loadLevel(areaName)
+}
+
+// loadScene loads the map area of the given scene and spawns its units and
+// props.
+func loadScene(s *scene.Scene) (*level, error) {
+	infof("loading assets")
+	layerFiles, err := s.LayerFiles()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	area, err := assets.LoadAreaLayers(s.Area, layerFiles)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	l := &level{
+		scene:  s,
+		spawns: make(map[int]int),
+		world:  sim.NewWorld(area),
+		cam:    camera.New(screenWidth, screenHeight, area.BackgroundLayer.Bounds()),
+		// Split background layer into tiles.
+		imgs: render.SplitTiles(render.BackgroundImage, area.BackgroundLayer, render.TileSize),
+	}
+	// Load walkability grid.
+	grid, created, err := nav.Load(area)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if created {
+		infof("creating %q", nav.CachePath(area))
+	}
+	l.world.Nav = grid
+	l.renderer = render.NewRenderer(l.imgs)
+	l.renderer.Occlusion = true
+	l.minimap = minimap.New(area)
+	// Load sprite sheets of unit types; animations are clipped to the frames
+	// present in the sprite sheet (e.g. graphics without shoot animation).
+	types := unitTypes()
+	l.types = types
+	for _, typ := range types {
+		sheetImg, err := imgutil.ReadFile(assets.FullPath(typ.SheetPath))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		typ.Sheet.Clip(sheetImg.Bounds())
+		l.imgs[typ.SheetPath] = sheetImg
+	}
+	// Generate sprite sheets of projectile types.
+	for name, img := range projectileImages() {
+		l.imgs[name] = img
+	}
+	// Place props.
+	for _, p := range s.Props {
+		if err := l.addProp(p); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	// Spawn units.
+	for i, spawn := range s.Spawns {
+		unit, err := l.spawn(types, spawn)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		l.spawns[unit.ID] = i
+	}
+	if player, ok := l.world.Player(); ok {
+		l.world.Selected = []int{player.ID}
+		l.cam.CenterOn(player.X, player.Y)
+	}
+	// Add lighting.
+	l.world.Lighting = defaultLighting()
+	if s.Lighting != nil {
+		lighting := *s.Lighting
+		lighting.Points = append([]light.PointLight(nil), s.Lighting.Points...)
+		l.world.Lighting = &lighting
+	}
+	l.torch = len(l.world.Lighting.Points)
+	l.world.Lighting.Points = append(l.world.Lighting.Points, playerTorch())
+	l.updateLighting()
+	l.exits = validExits(s)
+	debugf("loading assets (done)")
+	return l, nil
+}
+
+// spawn spawns a unit of the given scene spawn.
+func (l *level) spawn(types map[string]*entity.Type, spawn scene.Spawn) (*entity.Unit, error) {
+	typ, ok := types[spawn.Type]
+	if !ok {
+		return nil, errors.Errorf("unable to locate unit type %q", spawn.Type)
+	}
+	dir, err := scene.ParseFacing(spawn.Facing)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	unit := entity.NewUnit(typ, spawn.X, spawn.Y)
+	unit.Dir = dir
+	unit.Faction = entity.FactionMonster
+	if spawn.Faction == "player" {
+		unit.Faction = entity.FactionPlayer
+	}
+	l.world.Units.Add(unit)
+	if spawn.Player {
+		l.world.PlayerID = unit.ID
+	}
+	if len(spawn.AI) > 0 {
+		cfg, err := ai.Profile(spawn.AI)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		l.world.Controllers = append(l.world.Controllers, ai.NewBrain(unit, cfg))
+	}
+	return unit, nil
+}
+
+// addProp places a prop of the given scene prop, loading its image and
+// blocking movement around it.
+func (l *level) addProp(p scene.Prop) error {
+	if _, ok := l.imgs[p.Image]; !ok {
+		img, err := imgutil.ReadFile(assets.FullPath(p.Image))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		l.imgs[p.Image] = img
+	}
+	prop := &entity.Prop{
+		ID:        len(l.world.Props) + 1,
+		Name:      p.Name,
+		ImagePath: p.Image,
+		X:         p.X,
+		Y:         p.Y,
+		OffsetX:   p.OffsetX,
+		OffsetY:   p.OffsetY,
+		Radius:    p.Radius,
+	}
+	l.world.Props = append(l.world.Props, prop)
+	if prop.Radius > 0 && l.world.Nav != nil {
+		l.world.Nav.Block(prop.X, prop.Y, prop.Radius)
+	}
+	return nil
+}
+
+// validExits returns the exit regions of the given scene leading to existing
+// entry points of their destination areas; the destination area must have a
+// scene file defining the entry point. Invalid exits are reported and ignored.
+func validExits(s *scene.Scene) []scene.Exit {
+	var exits []scene.Exit
+	for _, exit := range s.Exits {
+		dest, ok, err := scene.LoadArea(exit.Dest)
+		if err != nil {
+			warnf("ignoring exit %q of area %q; %v", exit.Name, s.Area, err)
+			continue
+		}
+		if !ok {
+			warnf("ignoring exit %q of area %q; unable to locate scene of destination area %q", exit.Name, s.Area, exit.Dest)
+			continue
+		}
+		if _, ok := dest.EntryByName(exit.Entry); !ok {
+			warnf("ignoring exit %q of area %q; unable to locate entry point %q of destination area %q", exit.Name, s.Area, exit.Entry, exit.Dest)
+			continue
+		}
+		exits = append(exits, exit)
+	}
+	return exits
+}
+
+// defaultScene returns the default scene of map areas without scene file; the
+// player unit and a monster.
+func defaultScene(areaName string) *scene.Scene {
+	return &scene.Scene{
+		Area: areaName,
+		Spawns: []scene.Spawn{
+			{Type: "balrog", X: 760, Y: 760, Faction: "player", Player: true},
+			{Type: "balrog", X: 1060, Y: 860, AI: "monster"},
+		},
+	}
+}
+
+// followPlayer moves the camera smoothly towards the player unit by one
+// simulation tick.
+func (l *level) followPlayer() {
+	if player, ok := l.world.Player(); ok {
+		l.cam.Follow(player.X, player.Y, sim.TickDur)
+	}
+}
+
+// defaultLighting returns the default lighting of map areas; a dim ambient
+// light and moonlight from the top-left.
+func defaultLighting() *light.Scene {
+	return &light.Scene{
+		Ambient: light.Color{R: 0.45, G: 0.45, B: 0.5},
+		Dir: &light.DirLight{
+			Dir:       light.Vec3{X: -1, Y: -1, Z: 1},
+			Color:     light.Color{R: 0.6, G: 0.65, B: 0.8},
+			Intensity: 0.6,
+		},
+	}
+}
+
+// playerTorch returns the torch carried by the player unit.
+func playerTorch() light.PointLight {
+	return light.PointLight{
+		Z:         80,
+		Radius:    400,
+		Color:     light.Color{R: 1, G: 0.8, B: 0.5},
+		Intensity: 1.2,
+	}
+}
+
+// updateLighting moves the torch of the player unit to the position of the
+// player unit.
+func (l *level) updateLighting() {
+	player, ok := l.world.Player()
+	if !ok || l.world.Lighting == nil || l.torch >= len(l.world.Lighting.Points) {
+		return
+	}
+	torch := &l.world.Lighting.Points[l.torch]
+	torch.X, torch.Y = player.X, player.Y
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// logLevel specifies the verbosity of log messages.
+type logLevel uint8
+
+// Log levels, in order of increasing verbosity.
+const (
+	levelError logLevel = iota
+	levelWarn
+	levelInfo
+	levelDebug
+)
+
+// logLevelNames maps from log level name to log level.
+var logLevelNames = map[string]logLevel{
+	"error": levelError,
+	"warn":  levelWarn,
+	"info":  levelInfo,
+	"debug": levelDebug,
+}
+
+// parseLogLevel returns the log level of the given log level name (e.g.
+// "info").
+func parseLogLevel(name string) (logLevel, error) {
+	level, ok := logLevelNames[name]
+	if !ok {
+		return 0, errors.Errorf("invalid log level %q; expected error, warn, info or debug", name)
+	}
+	return level, nil
+}
+
+// curLogLevel specifies the log level of the current session; messages of
+// higher verbosity are discarded.
+var curLogLevel = levelInfo
+
+// warnf prints the given warning message, formatted as by fmt.Printf.
+func warnf(format string, args ...interface{}) {
+	logf(levelWarn, format, args...)
+}
+
+// infof prints the given informational message, formatted as by fmt.Printf.
+func infof(format string, args ...interface{}) {
+	logf(levelInfo, format, args...)
+}
+
+// debugf prints the given debug message, formatted as by fmt.Printf.
+func debugf(format string, args ...interface{}) {
+	logf(levelDebug, format, args...)
+}
+
+// logf prints the given message of the specified log level, formatted as by
+// fmt.Printf, unless discarded by the current log level.
+func logf(level logLevel, format string, args ...interface{}) {
+	if level > curLogLevel {
+		return
+	}
+	fmt.Printf(format+"\n", args...)
+}
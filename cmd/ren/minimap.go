@@ -0,0 +1,139 @@
+//go:build !headless
+// +build !headless
+
+package main
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+	"github.com/mewspring/ren/pkg/entity"
+	"github.com/mewspring/ren/pkg/input"
+	"github.com/mewspring/ren/pkg/render"
+	"github.com/mewspring/ren/pkg/render/ebitenrender"
+	"github.com/pkg/errors"
+)
+
+// Source image names of the minimap (see render.Op).
+const (
+	// Thumbnail of the current map area.
+	minimapImage = "minimap"
+	// Fog of war of the current map area.
+	minimapFogImage = "minimap_fog"
+)
+
+// Minimap layout, in screen pixels.
+const (
+	// Maximum width and height of the minimap.
+	minimapSize = 200
+	// Distance of the minimap from the top right corner of the screen.
+	minimapMargin = 8
+	// Width and height of unit markers.
+	markerSize = 3
+)
+
+// Minimap colors.
+var (
+	// Color of the minimap border.
+	minimapBorderColor = color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xFF}
+	// Color of the camera viewport rectangle.
+	minimapViewColor = color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+	// Color of the player unit marker.
+	playerMarkerColor = color.RGBA{R: 0xFF, G: 0xFF, B: 0x40, A: 0xFF}
+	// Colors of unit markers, indexed by faction.
+	factionMarkerColors = map[entity.Faction]color.RGBA{
+		entity.FactionPlayer:  {R: 0x30, G: 0xD0, B: 0x30, A: 0xFF},
+		entity.FactionMonster: {R: 0xE0, G: 0x30, B: 0x30, A: 0xFF},
+	}
+)
+
+// minimapRect returns the screen rectangle of the minimap, in the top right
+// corner of the screen.
+func (game *Game) minimapRect() image.Rectangle {
+	w, h := game.level.minimap.Size(minimapSize)
+	x := screenWidth - minimapMargin - w
+	return image.Rect(x, minimapMargin, x+w, minimapMargin+h)
+}
+
+// onMinimap reports whether the given screen position is on the minimap.
+func (game *Game) onMinimap(x, y int) bool {
+	return image.Pt(x, y).In(game.minimapRect())
+}
+
+// updateMinimap recenters the camera on the position of the minimap clicked or
+// dragged by the select action (left mouse button by default). Recentering
+// stops following of the player unit.
+func (game *Game) updateMinimap() {
+	cx, cy := game.input.CursorX, game.input.CursorY
+	if game.input.JustPressed(input.ActionSelect) && game.onMinimap(cx, cy) {
+		game.minimapDragging = true
+	}
+	if !game.input.Pressed(input.ActionSelect) {
+		game.minimapDragging = false
+	}
+	if !game.minimapDragging {
+		return
+	}
+	game.follow = false
+	x, y := game.level.minimap.ToWorld(game.minimapRect(), float64(cx), float64(cy))
+	game.level.cam.CenterOn(x, y)
+}
+
+// drawMinimap draws the minimap to the render target; the thumbnail of the map
+// area covered by fog of war, the unit markers and the camera viewport.
+// Hostile units are only shown while in sight of the party of the player.
+func (game *Game) drawMinimap(t *ebitenrender.EbitenTarget) error {
+	l := game.level
+	m := l.minimap
+	r := game.minimapRect()
+	ebitenutil.DrawRect(t.Screen, float64(r.Min.X-1), float64(r.Min.Y-1), float64(r.Dx()+2), float64(r.Dy()+2), minimapBorderColor)
+	thumb := minimapOp(minimapImage, m.Thumb, 0, r, float64(r.Dx())/float64(m.Thumb.Bounds().Dx()))
+	if err := t.Draw(thumb); err != nil {
+		return errors.WithStack(err)
+	}
+	fogImg, fogRev := m.Fog.Image()
+	fogScale := float64(m.Fog.CellSize) * float64(r.Dx()) / float64(m.Bounds.Dx())
+	fog := minimapOp(minimapFogImage, fogImg, fogRev, r, fogScale)
+	if err := t.Draw(fog); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, unit := range l.world.Units.Units {
+		if unit.Dead() {
+			continue
+		}
+		c, ok := factionMarkerColors[unit.Faction]
+		if !ok {
+			continue
+		}
+		if unit.Faction != entity.FactionPlayer && !l.inSight(unit.X, unit.Y) {
+			continue
+		}
+		if unit.ID == l.world.PlayerID {
+			c = playerMarkerColor
+		}
+		x, y := m.ToMap(r, unit.X, unit.Y)
+		ebitenutil.DrawRect(t.Screen, x-markerSize/2, y-markerSize/2, markerSize, markerSize, c)
+	}
+	view := l.cam.Viewport().Intersect(m.Bounds)
+	x0, y0 := m.ToMap(r, float64(view.Min.X), float64(view.Min.Y))
+	x1, y1 := m.ToMap(r, float64(view.Max.X), float64(view.Max.Y))
+	ebitenutil.DrawLine(t.Screen, x0, y0, x1, y0, minimapViewColor)
+	ebitenutil.DrawLine(t.Screen, x1, y0, x1, y1, minimapViewColor)
+	ebitenutil.DrawLine(t.Screen, x1, y1, x0, y1, minimapViewColor)
+	ebitenutil.DrawLine(t.Screen, x0, y1, x0, y0, minimapViewColor)
+	return nil
+}
+
+// minimapOp returns the draw operation of the given minimap image at the top
+// left corner of the given screen rectangle.
+func minimapOp(src string, img image.Image, rev int, r image.Rectangle, scale float64) render.Op {
+	return render.Op{
+		Src:   src,
+		Img:   img,
+		Rev:   rev,
+		X:     float64(r.Min.X),
+		Y:     float64(r.Min.Y),
+		Scale: scale,
+	}
+}
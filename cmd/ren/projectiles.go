@@ -0,0 +1,114 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/mewspring/ren/pkg/anim"
+	"github.com/mewspring/ren/pkg/entity"
+	"github.com/mewspring/ren/pkg/facing"
+)
+
+// fireballType returns the fireball projectile type. Its sprite sheet is
+// generated by fireballImage.
+func fireballType() *entity.ProjectileType {
+	const (
+		// Frame size of fireball sprite sheet.
+		frameSize = 48
+		// Flight height above ground in pixels.
+		height = 64
+		// Number of frames of flight animation.
+		nframes = 4
+		// Duration per frame.
+		durPerFrame = 80 * time.Millisecond
+	)
+	sheet := &anim.Sheet{
+		FrameWidth:  frameSize,
+		FrameHeight: frameSize,
+		OffsetX:     frameSize / 2,
+		OffsetY:     frameSize/2 + height,
+		Defs: map[anim.State]anim.Def{
+			anim.StateStance: {
+				FirstFrame: 0,
+				NFrames:    nframes,
+				Dur:        nframes * durPerFrame,
+				AnimType:   anim.AnimTypeLoop,
+			},
+		},
+	}
+	return &entity.ProjectileType{
+		Name:      "fireball",
+		SheetPath: "fireball",
+		Sheet:     sheet,
+		Speed:     600,
+		Range:     800,
+		Radius:    40,
+		Damage:    25,
+		Splash:    60,
+	}
+}
+
+// projectileTypes returns the projectile types of the game, indexed by
+// projectile type name.
+func projectileTypes() map[string]*entity.ProjectileType {
+	m := make(map[string]*entity.ProjectileType)
+	for _, typ := range []*entity.ProjectileType{fireballType()} {
+		m[typ.Name] = typ
+	}
+	return m
+}
+
+// projectileImages returns the generated sprite sheets of projectile types,
+// indexed by source image name.
+func projectileImages() map[string]image.Image {
+	typ := fireballType()
+	return map[string]image.Image{
+		typ.SheetPath: fireballImage(typ.Sheet),
+	}
+}
+
+// fireballImage returns the sprite sheet of fireballs; a pulsing ball of fire
+// trailing a tail opposite to its flight direction.
+func fireballImage(sheet *anim.Sheet) image.Image {
+	def := sheet.Defs[anim.StateStance]
+	w, h := sheet.FrameWidth, sheet.FrameHeight
+	img := image.NewRGBA(image.Rect(0, 0, def.NFrames*w, facing.NDirs*h))
+	for row, dir := range facing.FlareLayout {
+		// Tail direction.
+		tx, ty := dir.ScreenVector()
+		tx, ty = -tx, -ty
+		for frame := 0; frame < def.NFrames; frame++ {
+			pulse := 1 + 0.15*math.Sin(float64(frame)/float64(def.NFrames)*2*math.Pi)
+			r := float64(w) / 6 * pulse
+			cx := float64(w)/2 - tx*r
+			cy := float64(h)/2 - ty*r
+			x0, y0 := frame*w, row*h
+			for y := 0; y < h; y++ {
+				for x := 0; x < w; x++ {
+					px, py := float64(x)+0.5-cx, float64(y)+0.5-cy
+					// Distance along and across the tail.
+					along := px*tx + py*ty
+					across := -px*ty + py*tx
+					d := math.Hypot(px, py) / r
+					if along > 0 {
+						// Stretch the ball into a tail.
+						d = math.Hypot(along/(2.5*r), across/r)
+					}
+					if d >= 1 {
+						continue
+					}
+					a := 1 - d
+					img.SetRGBA(x0+x, y0+y, color.RGBA{
+						R: uint8(255 * a),
+						G: uint8(255 * a * a * (1 - 0.5*d)),
+						B: uint8(128 * a * a * a),
+						A: uint8(255 * a),
+					})
+				}
+			}
+		}
+	}
+	return img
+}
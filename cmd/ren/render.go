@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mewkiz/pkg/imgutil"
+	"github.com/mewspring/ren/pkg/assets"
+	"github.com/mewspring/ren/pkg/render"
+	"github.com/mewspring/ren/pkg/sim"
+	"github.com/pkg/errors"
+)
+
+// renderCmd renders frames of the starting map area of the configuration to
+// PNG images, without requiring a GPU or display.
+func renderCmd(args []string) error {
+	var (
+		// Number of frames to render.
+		nframes int
+		// Output directory.
+		outDir string
+		// Light background layer.
+		lighting bool
+		// Occlude units behind scenery.
+		occlusion bool
+		// Layer drawn as background.
+		layerName string
+	)
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	fs.IntVar(&nframes, "frames", 1, "number of frames to render")
+	fs.StringVar(&outDir, "out", "frames", "output directory")
+	fs.BoolVar(&lighting, "light", false, "light background layer using normal layer")
+	fs.BoolVar(&occlusion, "occlude", true, "occlude units behind scenery using height layer")
+	fs.StringVar(&layerName, "layer", "background", "layer drawn as background (background, normal, height, as or walkability)")
+	cfg, err := parseConfig(fs, args)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if fs.NArg() > 0 {
+		return errors.Errorf("unexpected arguments %q", fs.Args())
+	}
+	if err := applyConfig(cfg); err != nil {
+		return errors.WithStack(err)
+	}
+	l, err := loadLevelScene(cfg.Area, cfg.Scene)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	t := render.NewImageTarget(screenWidth, screenHeight)
+	t.Imgs = l.imgs
+	l.renderer.Lighting = lighting
+	l.renderer.Occlusion = occlusion
+	if err := showLayer(l.renderer, layerName); err != nil {
+		return errors.WithStack(err)
+	}
+	for i := 0; i < nframes; i++ {
+		l.world.Update(sim.Input{})
+		l.followPlayer()
+		l.updateLighting()
+		t.Clear()
+		if err := l.renderer.DrawWorld(t, l.world, l.cam); err != nil {
+			return errors.WithStack(err)
+		}
+		framePath := filepath.Join(outDir, fmt.Sprintf("frame_%04d.png", i))
+		infof("creating %q", framePath)
+		if err := imgutil.WriteFile(framePath, t.Dst); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// showLayer selects the layer drawn by the given renderer as background, by
+// layer name (e.g. "height"); the walkability grid is selected by the name
+// "walkability".
+func showLayer(r *render.Renderer, name string) error {
+	r.Layer = 0
+	r.Walkability = false
+	if name == render.WalkabilityImage {
+		r.Walkability = true
+		return nil
+	}
+	kind, err := assets.ParseLayerKind(name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	r.Layer = kind
+	return nil
+}
+
+// Logical screen size; set by the configuration of interactive play.
+var (
+	screenWidth  = 1280
+	screenHeight = 768
+)
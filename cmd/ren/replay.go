@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/mewspring/ren/pkg/replay"
+	"github.com/mewspring/ren/pkg/save"
+	"github.com/pkg/errors"
+)
+
+// recordingsDir specifies the directory of recorded play sessions.
+const recordingsDir = "recordings"
+
+// replayCmd replays a recorded play session headlessly, verifying the checksum
+// of each simulation tick.
+func replayCmd(args []string) error {
+	var (
+		// Save game file of final game state.
+		savePath string
+	)
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	fs.StringVar(&savePath, "save", "", "save game file of final game state")
+	cfg, err := parseConfig(fs, args)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if fs.NArg() != 1 {
+		return errors.New("invalid number of arguments; usage: ren replay [OPTION]... FILE")
+	}
+	if err := applyConfig(cfg); err != nil {
+		return errors.WithStack(err)
+	}
+	path := fs.Arg(0)
+	rec, err := replay.Read(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	l, areas, err := restore(rec.Start)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	l.world.Reseed(rec.Seed)
+	for i, tick := range rec.Ticks {
+		l.world.Paused = tick.Paused
+		l.world.Update(tick.Input)
+		l.revealFog()
+		if sum := l.world.Checksum(); sum != tick.Checksum {
+			return errors.Errorf("desync at tick %d of recording %q; expected checksum %016x, got %016x", i, path, tick.Checksum, sum)
+		}
+		if exit, ok := l.checkExit(); ok {
+			// Failed area transitions are cancelled, as in play.
+			next, err := changeArea(l, areas, exit)
+			if err != nil {
+				warnf("unable to change area through exit %q; %v", exit.Name, err)
+				continue
+			}
+			l = next
+		}
+	}
+	fmt.Printf("replayed %d ticks of %q; area %q, tick %d, checksum %016x\n", len(rec.Ticks), path, l.scene.Area, l.world.Tick, l.world.Checksum())
+	if len(savePath) > 0 {
+		if err := save.Write(savePath, snapshot(l, areas)); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mewspring/ren/pkg/ai"
+	"github.com/mewspring/ren/pkg/anim"
+	"github.com/mewspring/ren/pkg/entity"
+	"github.com/mewspring/ren/pkg/facing"
+	"github.com/mewspring/ren/pkg/pathfind"
+	"github.com/mewspring/ren/pkg/save"
+	"github.com/pkg/errors"
+)
+
+// Save game slots.
+const (
+	// saveDir specifies the save slot directory.
+	saveDir = "saves"
+	// quicksaveSlot specifies the save slot of quicksaves.
+	quicksaveSlot = "quicksave"
+)
+
+// snapshot returns a save game of the given level and persistent state of
+// visited map areas.
+func snapshot(l *level, areas map[string]*areaState) *save.Save {
+	w := l.world
+	s := &save.Save{
+		Area:      l.scene.Area,
+		Scene:     l.scenePath,
+		Tick:      w.Tick,
+		Paused:    w.Paused,
+		Seed:      w.Seed,
+		RandState: w.RandState(),
+		Camera: save.Camera{
+			X:    l.cam.X,
+			Y:    l.cam.Y,
+			Zoom: l.cam.Zoom,
+		},
+		PlayerID: w.PlayerID,
+		Selected: append([]int(nil), w.Selected...),
+		Explored: l.minimap.Fog.Bitmap(),
+		Areas:    make(map[string]save.Area),
+	}
+	for _, unit := range w.Units.Units {
+		spawn, ok := l.spawns[unit.ID]
+		if !ok {
+			spawn = -1
+		}
+		su := save.Unit{
+			ID:       unit.ID,
+			Type:     unit.Type.Name,
+			Spawn:    spawn,
+			X:        unit.X,
+			Y:        unit.Y,
+			Facing:   unit.Dir.String(),
+			Faction:  unit.Faction.String(),
+			HP:       unit.HP,
+			Anim:     save.Anim{State: unit.Anim.State.String()},
+			Target:   unit.Target,
+			AimX:     unit.AimX,
+			AimY:     unit.AimY,
+			Attacker: unit.Attacker,
+		}
+		if a, ok := unit.Anim.Anim(); ok {
+			su.Anim.Frame = a.CurFrame
+			su.Anim.Inc = a.Inc
+			su.Anim.Elapsed = int64(a.Elapsed)
+		}
+		for _, p := range unit.Path {
+			su.Path = append(su.Path, save.Point{X: p.X, Y: p.Y})
+		}
+		s.Units = append(s.Units, su)
+	}
+	for _, p := range w.Projectiles {
+		sp := save.Projectile{
+			ID:        p.ID,
+			Type:      p.Type.Name,
+			OwnerID:   p.OwnerID,
+			Faction:   p.Faction.String(),
+			X:         p.X,
+			Y:         p.Y,
+			DX:        p.DX,
+			DY:        p.DY,
+			Travelled: p.Travelled,
+		}
+		s.Projectiles = append(s.Projectiles, sp)
+	}
+	for _, c := range w.Controllers {
+		b, ok := c.(*ai.Brain)
+		if !ok {
+			continue
+		}
+		sb := save.Brain{
+			UnitID:      b.UnitID,
+			State:       b.State.String(),
+			HomeX:       b.HomeX,
+			HomeY:       b.HomeY,
+			IdleTicks:   b.IdleTicks,
+			AggroRadius: b.Config.AggroRadius,
+			LeashRadius: b.Config.LeashRadius,
+			Wander:      b.Config.Wander,
+			MinIdle:     int64(b.Config.MinIdle),
+			MaxIdle:     int64(b.Config.MaxIdle),
+			FleeHealth:  b.Config.FleeHealth,
+			FleeDist:    b.Config.FleeDist,
+		}
+		s.Brains = append(s.Brains, sb)
+	}
+	for name, st := range areas {
+		if name == l.scene.Area {
+			// The state of the current map area is held by its units.
+			continue
+		}
+		var dead []int
+		for i := range st.dead {
+			dead = append(dead, i)
+		}
+		sort.Ints(dead)
+		s.Areas[name] = save.Area{Dead: dead, Explored: st.explored}
+	}
+	return s
+}
+
+// restore returns the level and persistent state of visited map areas of the
+// given save game.
+func restore(s *save.Save) (*level, map[string]*areaState, error) {
+	l, err := loadLevelScene(s.Area, s.Scene)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	if l.scene.Area != s.Area {
+		return nil, nil, errors.Errorf("area mismatch of scene %q; expected %q, got %q", s.Scene, s.Area, l.scene.Area)
+	}
+	w := l.world
+	// Replace the units, projectiles and brains spawned by the scene with those
+	// of the save game.
+	w.Units = entity.NewCollection()
+	w.Projectiles = nil
+	w.Controllers = nil
+	l.spawns = make(map[int]int)
+	for _, su := range s.Units {
+		unit, err := restoreUnit(l.types, su)
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+		w.Units.Insert(unit)
+		if su.Spawn >= 0 {
+			l.spawns[unit.ID] = su.Spawn
+		}
+	}
+	projectileTypes := projectileTypes()
+	for _, sp := range s.Projectiles {
+		typ, ok := projectileTypes[sp.Type]
+		if !ok {
+			return nil, nil, errors.Errorf("unable to locate projectile type %q", sp.Type)
+		}
+		faction, err := entity.ParseFaction(sp.Faction)
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+		p := &entity.Projectile{
+			ID:        sp.ID,
+			Type:      typ,
+			OwnerID:   sp.OwnerID,
+			Faction:   faction,
+			X:         sp.X,
+			Y:         sp.Y,
+			DX:        sp.DX,
+			DY:        sp.DY,
+			Travelled: sp.Travelled,
+			Anim:      typ.Sheet.Defs[anim.StateStance].NewAnim(),
+		}
+		if dir, ok := facing.FromScreen(sp.DX, sp.DY); ok {
+			p.Dir = dir
+		}
+		w.InsertProjectile(p)
+	}
+	for _, sb := range s.Brains {
+		state, err := ai.ParseState(sb.State)
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+		b := &ai.Brain{
+			UnitID: sb.UnitID,
+			Config: ai.Config{
+				AggroRadius: sb.AggroRadius,
+				LeashRadius: sb.LeashRadius,
+				Wander:      sb.Wander,
+				MinIdle:     time.Duration(sb.MinIdle),
+				MaxIdle:     time.Duration(sb.MaxIdle),
+				FleeHealth:  sb.FleeHealth,
+				FleeDist:    sb.FleeDist,
+			},
+			State:     state,
+			HomeX:     sb.HomeX,
+			HomeY:     sb.HomeY,
+			IdleTicks: sb.IdleTicks,
+		}
+		w.Controllers = append(w.Controllers, b)
+	}
+	w.PlayerID = s.PlayerID
+	w.Selected = append([]int(nil), s.Selected...)
+	w.Tick = s.Tick
+	w.Paused = s.Paused
+	w.Reseed(s.Seed)
+	w.SetRandState(s.RandState)
+	l.cam.X, l.cam.Y = s.Camera.X, s.Camera.Y
+	if s.Camera.Zoom > 0 {
+		l.cam.Zoom = s.Camera.Zoom
+	}
+	l.cam.Clamp()
+	if s.Explored != nil {
+		l.restoreFog(s.Explored)
+	}
+	// Loading within an exit region does not trigger the exit.
+	if player, ok := w.Player(); ok {
+		_, l.inExit = l.exitAt(player.X, player.Y)
+	}
+	l.updateLighting()
+	areas := make(map[string]*areaState)
+	for name, sa := range s.Areas {
+		st := &areaState{dead: make(map[int]bool), explored: sa.Explored}
+		for _, i := range sa.Dead {
+			st.dead[i] = true
+		}
+		areas[name] = st
+	}
+	return l, areas, nil
+}
+
+// restoreUnit returns the unit of the given saved unit state.
+func restoreUnit(types map[string]*entity.Type, su save.Unit) (*entity.Unit, error) {
+	typ, ok := types[su.Type]
+	if !ok {
+		return nil, errors.Errorf("unable to locate unit type %q", su.Type)
+	}
+	dir, err := facing.Parse(su.Facing)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	faction, err := entity.ParseFaction(su.Faction)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	state, err := anim.ParseState(su.Anim.State)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	unit := entity.NewUnit(typ, su.X, su.Y)
+	unit.ID = su.ID
+	unit.Dir = dir
+	unit.Faction = faction
+	unit.HP = su.HP
+	unit.Target = su.Target
+	unit.AimX, unit.AimY = su.AimX, su.AimY
+	unit.Attacker = su.Attacker
+	for _, p := range su.Path {
+		unit.Path = append(unit.Path, pathfind.Point{X: p.X, Y: p.Y})
+	}
+	if a, ok := unit.Anim.Anims[state]; ok {
+		unit.Anim.State = state
+		a.CurFrame = su.Anim.Frame
+		a.Inc = su.Anim.Inc
+		a.Elapsed = time.Duration(su.Anim.Elapsed)
+	}
+	return unit, nil
+}
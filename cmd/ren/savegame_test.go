@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mewspring/ren/pkg/assets"
+	"github.com/mewspring/ren/pkg/save"
+	"github.com/mewspring/ren/pkg/scene"
+	"github.com/mewspring/ren/pkg/sim"
+)
+
+func TestSaveRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "savegame")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	scenePath := writeTestAssets(t, dir)
+	l, err := loadSceneFile(scenePath)
+	if err != nil {
+		t.Fatalf("unable to load scene; %+v", err)
+	}
+	areas := map[string]*areaState{"other": {dead: map[int]bool{1: true}}}
+
+	// Play for a while, to advance the random number generator by wandering
+	// monsters; then save and load the game.
+	for i := 0; i < 2*sim.TPS; i++ {
+		l.world.Update(sim.Input{MoveX: 1})
+	}
+	path := save.SlotPath(filepath.Join(dir, saveDir), quicksaveSlot)
+	if err := save.Write(path, snapshot(l, areas)); err != nil {
+		t.Fatalf("unable to write save game; %+v", err)
+	}
+	s, err := save.Read(path)
+	if err != nil {
+		t.Fatalf("unable to read save game; %+v", err)
+	}
+	loaded, loadedAreas, err := restore(s)
+	if err != nil {
+		t.Fatalf("unable to restore save game; %+v", err)
+	}
+	want, got := snapshot(l, areas), snapshot(loaded, loadedAreas)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("game state mismatch of loaded game; expected %+v, got %+v", want, got)
+	}
+
+	// The loaded game continues as the saved game would have, random decisions
+	// of monsters included.
+	for i := 0; i < 10*sim.TPS; i++ {
+		in := sim.Input{MoveY: 1}
+		if i%60 == 0 {
+			in = sim.Input{Attack: true, TargetX: 400, TargetY: 300}
+		}
+		l.world.Update(in)
+		loaded.world.Update(in)
+		want, got := snapshot(l, areas), snapshot(loaded, loadedAreas)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("game state mismatch of loaded game at tick %d; expected %+v, got %+v", l.world.Tick, want, got)
+		}
+	}
+}
+
+// writeTestAssets writes the assets of a small map area to the given directory,
+// and uses it as the assets directory until the end of the test. The returned
+// path is the scene file of the map area; of a player unit and wandering
+// monsters.
+func writeTestAssets(t *testing.T, dir string) string {
+	oldDir := assets.AssetsDir
+	assets.AssetsDir = dir
+	t.Cleanup(func() { assets.AssetsDir = oldDir })
+	layers := map[string]image.Image{
+		"test_background.png": uniform(640, 480, color.Gray{Y: 0x40}),
+		"test_normal.png":     uniform(320, 240, color.RGBA{R: 0x80, G: 0x80, B: 0xFF, A: 0xFF}),
+		"test_height.png":     uniform(640, 480, color.Gray{}),
+		"test_as.png":         uniform(320, 240, color.Gray{}),
+	}
+	// Sprite sheet of the balrog unit type; 57 frames in 8 directions.
+	typ := balrogType()
+	layers[typ.SheetPath] = uniform(57*typ.Sheet.FrameWidth, 8*typ.Sheet.FrameHeight, color.Gray{Y: 0xFF})
+	for name, img := range layers {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := png.Encode(f, img); err != nil {
+			f.Close()
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &scene.Scene{
+		Area: "test",
+		Spawns: []scene.Spawn{
+			{Type: "balrog", X: 100, Y: 100, Faction: "player", Player: true},
+			{Type: "balrog", X: 400, Y: 300, AI: "monster"},
+			{Type: "balrog", X: 500, Y: 200, AI: "monster"},
+		},
+	}
+	buf, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scenePath := filepath.Join(dir, "test_scene.json")
+	if err := ioutil.WriteFile(scenePath, buf, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return scenePath
+}
+
+// uniform returns an image of the given dimensions, filled with the given
+// colour.
+func uniform(width, height int, c color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/mewspring/ren/pkg/anim"
+	"github.com/mewspring/ren/pkg/entity"
+)
+
+// unitTypes returns the unit types of the game, indexed by unit type name.
+func unitTypes() map[string]*entity.Type {
+	m := make(map[string]*entity.Type)
+	for _, typ := range []*entity.Type{balrogType()} {
+		m[typ.Name] = typ
+	}
+	return m
+}
+
+// balrogType returns the Balrog unit type.
+func balrogType() *entity.Type {
+	const (
+		// render_size=160,160
+		// render_offset=80,80
+		frameWidth  = 160
+		frameHeight = 160
+		offsetX     = 80
+		offsetY     = 80
+		// [stance]
+		// position=0
+		// frames=6
+		// duration=300ms
+		// type=back_forth
+		standFirstFrame = 0
+		standNFrames    = 6
+		// [run]
+		// position=6
+		// frames=7
+		// duration=7ms
+		// type=looped
+		walkFirstFrame = 6
+		walkNFrames    = 7
+		// [swing]
+		// position=13
+		// frames=14
+		// duration=14ms
+		// type=play_once
+		attackFirstFrame = 13
+		attackNFrames    = 14
+		// Frame of swing animation at which the attack connects.
+		attackActiveFrame = 8
+		// [hit]
+		// position=27
+		// frames=1
+		// duration=1ms
+		// type=play_once
+		hitFirstFrame = 27
+		hitNFrames    = 1
+		// [die]
+		// position=28
+		// frames=24
+		// duration=24ms
+		// type=play_once
+		deathFirstFrame = 28
+		deathNFrames    = 24
+		// [shoot]
+		// position=52
+		// frames=5
+		// duration=5ms
+		// type=play_once
+		specialFirstFrame = 52
+		specialNFrames    = 5
+		// Frame of shoot animation at which the projectile is released.
+		specialActiveFrame = 3
+		// Duration per frame.
+		durPerFrame = 50 * time.Millisecond
+	)
+	sheet := &anim.Sheet{
+		FrameWidth:  frameWidth,
+		FrameHeight: frameHeight,
+		OffsetX:     offsetX,
+		OffsetY:     offsetY,
+		Defs: map[anim.State]anim.Def{
+			anim.StateStance: {
+				FirstFrame: standFirstFrame,
+				NFrames:    standNFrames,
+				Dur:        standNFrames * durPerFrame,
+				AnimType:   anim.AnimTypeBackForth,
+			},
+			anim.StateRun: {
+				FirstFrame: walkFirstFrame,
+				NFrames:    walkNFrames,
+				Dur:        walkNFrames * durPerFrame,
+				AnimType:   anim.AnimTypeLoop,
+			},
+			anim.StateSwing: {
+				FirstFrame:  attackFirstFrame,
+				NFrames:     attackNFrames,
+				Dur:         attackNFrames * durPerFrame,
+				AnimType:    anim.AnimTypeOnce,
+				ActiveFrame: attackActiveFrame,
+			},
+			anim.StateHit: {
+				FirstFrame: hitFirstFrame,
+				NFrames:    hitNFrames,
+				Dur:        hitNFrames * durPerFrame,
+				AnimType:   anim.AnimTypeOnce,
+			},
+			anim.StateDie: {
+				FirstFrame: deathFirstFrame,
+				NFrames:    deathNFrames,
+				Dur:        deathNFrames * durPerFrame,
+				AnimType:   anim.AnimTypeOnce,
+			},
+			anim.StateShoot: {
+				FirstFrame:  specialFirstFrame,
+				NFrames:     specialNFrames,
+				Dur:         specialNFrames * durPerFrame,
+				AnimType:    anim.AnimTypeOnce,
+				ActiveFrame: specialActiveFrame,
+			},
+		},
+	}
+	return &entity.Type{
+		Name:      "balrog",
+		SheetPath: filepath.Join("monsters", "balrog.png"),
+		Sheet:     sheet,
+		Stats: entity.Stats{
+			MaxHP:  100,
+			Speed:  300, // 5 pixels per frame at 60 FPS.
+			Damage: 15,
+			Range:  100,
+		},
+		Projectile: fireballType(),
+	}
+}
@@ -3,7 +3,8 @@ module github.com/mewspring/ren
 go 1.14
 
 require (
-	github.com/hajimehoshi/ebiten v1.10.5
+	github.com/hajimehoshi/ebiten v1.12.12
 	github.com/mewkiz/pkg v0.0.0-20200326183754-3fe813a3ca68
 	github.com/pkg/errors v0.9.1
+	golang.org/x/image v0.0.0-20200801110659-972c09e46d76
 )
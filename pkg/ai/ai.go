@@ -0,0 +1,272 @@
+package ai
+
+import (
+	"math"
+	"time"
+
+	"github.com/mewspring/ren/pkg/entity"
+	"github.com/mewspring/ren/pkg/sim"
+	"github.com/pkg/errors"
+)
+
+// Config holds the behaviour parameters of a monster.
+type Config struct {
+	// Distance in pixels within which hostile units in line of sight are
+	// noticed. Monsters damaged by units outside of it retaliate regardless.
+	AggroRadius float64
+	// Maximum distance in pixels from the home position; monsters wander within
+	// the leash and give up chasing targets outside of it.
+	LeashRadius float64
+	// Specifies whether the monster wanders when idle.
+	Wander bool
+	// Minimum and maximum duration of idling between wanders.
+	MinIdle, MaxIdle time.Duration
+	// Fraction of maximum health at or below which the monster flees; or 0 to
+	// never flee.
+	FleeHealth float64
+	// Distance in pixels fled per flee move.
+	FleeDist float64
+}
+
+// DefaultConfig returns the default behaviour parameters of monsters.
+func DefaultConfig() Config {
+	return Config{
+		AggroRadius: 400,
+		LeashRadius: 600,
+		Wander:      true,
+		MinIdle:     2 * time.Second,
+		MaxIdle:     5 * time.Second,
+		FleeHealth:  0.2,
+		FleeDist:    300,
+	}
+}
+
+// Profile returns the behaviour parameters of the given AI profile name;
+// "monster" (the default behaviour), "guard" (stands guard without wandering or
+// fleeing) or "coward" (flees early).
+func Profile(name string) (Config, error) {
+	cfg := DefaultConfig()
+	switch name {
+	case "monster":
+	case "guard":
+		cfg.Wander = false
+		cfg.LeashRadius = 400
+		cfg.FleeHealth = 0
+	case "coward":
+		cfg.FleeHealth = 0.5
+	default:
+		return Config{}, errors.Errorf("invalid AI profile %q; expected monster, guard or coward", name)
+	}
+	return cfg, nil
+}
+
+// Brain is a state machine controlling the behaviour of a monster, updated once
+// per simulation tick. Brain implements sim.Controller.
+type Brain struct {
+	// ID of controlled unit.
+	UnitID int
+	// Behaviour parameters.
+	Config Config
+	// Current behaviour state.
+	State State
+	// Home position, in area pixel coordinates.
+	HomeX, HomeY float64
+	// Number of ticks left to idle before wandering.
+	IdleTicks int
+}
+
+// NewBrain returns a new brain controlling the given unit, with its home at the
+// current position of the unit.
+func NewBrain(unit *entity.Unit, cfg Config) *Brain {
+	return &Brain{
+		UnitID: unit.ID,
+		Config: cfg,
+		State:  StateIdle,
+		HomeX:  unit.X,
+		HomeY:  unit.Y,
+	}
+}
+
+// Update updates the behaviour of the controlled unit by one simulation tick.
+func (b *Brain) Update(w *sim.World) {
+	unit, ok := w.Units.ByID(b.UnitID)
+	if !ok || unit.Dead() {
+		return
+	}
+	if b.State != StateFlee && b.lowHealth(unit) {
+		if threat, ok := b.nearestHostile(w, unit); ok {
+			b.flee(w, unit, threat)
+			return
+		}
+	}
+	if b.retaliate(w, unit) {
+		return
+	}
+	switch b.State {
+	case StateIdle:
+		if b.aggro(w, unit) {
+			return
+		}
+		if !b.Config.Wander {
+			return
+		}
+		if b.IdleTicks > 0 {
+			b.IdleTicks--
+			return
+		}
+		b.wander(w, unit)
+	case StateWander:
+		if b.aggro(w, unit) {
+			return
+		}
+		if len(unit.Path) == 0 {
+			b.idle(w)
+		}
+	case StateChase, StateAttack:
+		target, ok := w.Units.ByID(unit.Target)
+		if !ok || target.Dead() || b.distHome(target) > b.Config.LeashRadius {
+			b.returnHome(w, unit)
+			return
+		}
+		if dist(unit, target) <= unit.Stats.Range {
+			b.State = StateAttack
+		} else {
+			b.State = StateChase
+		}
+	case StateFlee:
+		if len(unit.Path) > 0 {
+			return
+		}
+		if threat, ok := b.nearestHostile(w, unit); ok {
+			b.flee(w, unit, threat)
+			return
+		}
+		b.idle(w)
+	case StateReturn:
+		if len(unit.Path) == 0 {
+			b.idle(w)
+		}
+	}
+}
+
+// aggro makes the unit attack the nearest hostile unit in sight, if any. The
+// boolean return value indicates if a hostile unit was noticed.
+func (b *Brain) aggro(w *sim.World, unit *entity.Unit) bool {
+	target, ok := b.nearestHostile(w, unit)
+	if !ok || b.distHome(target) > b.Config.LeashRadius {
+		return false
+	}
+	b.State = StateChase
+	w.AttackGroup([]*entity.Unit{unit}, target)
+	return true
+}
+
+// retaliate makes the unit attack the unit which last damaged it, even from
+// outside of the aggro radius; or flee from it at low health. Units already
+// fighting or fleeing keep doing so. The boolean return value indicates if the
+// unit retaliated.
+func (b *Brain) retaliate(w *sim.World, unit *entity.Unit) bool {
+	if unit.Attacker == 0 {
+		return false
+	}
+	attacker, ok := w.Units.ByID(unit.Attacker)
+	unit.Attacker = 0
+	if !ok || attacker.Dead() || !unit.Hostile(attacker) {
+		return false
+	}
+	switch b.State {
+	case StateChase, StateAttack, StateFlee:
+		return false
+	}
+	if b.lowHealth(unit) {
+		b.flee(w, unit, attacker)
+		return true
+	}
+	b.State = StateChase
+	w.AttackGroup([]*entity.Unit{unit}, attacker)
+	return true
+}
+
+// wander moves the unit to a random position within the leash of its home
+// position.
+func (b *Brain) wander(w *sim.World, unit *entity.Unit) {
+	angle := w.Rand.Float64() * 2 * math.Pi
+	r := b.Config.LeashRadius * math.Sqrt(w.Rand.Float64())
+	x := b.HomeX + r*math.Cos(angle)
+	y := b.HomeY + r*math.Sin(angle)
+	if !w.MoveTo(unit, x, y) {
+		b.idle(w)
+		return
+	}
+	b.State = StateWander
+}
+
+// flee moves the unit away from the given threat.
+func (b *Brain) flee(w *sim.World, unit *entity.Unit, threat *entity.Unit) {
+	dx, dy := unit.X-threat.X, unit.Y-threat.Y
+	d := math.Hypot(dx, dy)
+	if d == 0 {
+		dx, dy, d = 1, 0, 1
+	}
+	x := unit.X + dx/d*b.Config.FleeDist
+	y := unit.Y + dy/d*b.Config.FleeDist
+	unit.Target = 0
+	w.MoveTo(unit, x, y)
+	b.State = StateFlee
+}
+
+// returnHome stops attacking and moves the unit back to its home position.
+func (b *Brain) returnHome(w *sim.World, unit *entity.Unit) {
+	unit.Target = 0
+	w.MoveTo(unit, b.HomeX, b.HomeY)
+	b.State = StateReturn
+}
+
+// idle makes the unit idle for a random duration.
+func (b *Brain) idle(w *sim.World) {
+	minTicks := int(b.Config.MinIdle / sim.TickDur)
+	maxTicks := int(b.Config.MaxIdle / sim.TickDur)
+	b.IdleTicks = minTicks
+	if maxTicks > minTicks {
+		b.IdleTicks += w.Rand.Intn(maxTicks - minTicks)
+	}
+	b.State = StateIdle
+}
+
+// lowHealth reports whether the health of the unit is low enough to flee.
+func (b *Brain) lowHealth(unit *entity.Unit) bool {
+	return float64(unit.HP) <= b.Config.FleeHealth*float64(unit.Stats.MaxHP)
+}
+
+// nearestHostile returns the nearest living hostile unit within aggro radius
+// and line of sight of the given unit. The boolean return value indicates if
+// such a unit was found.
+func (b *Brain) nearestHostile(w *sim.World, unit *entity.Unit) (*entity.Unit, bool) {
+	var nearest *entity.Unit
+	minDist := b.Config.AggroRadius
+	for _, other := range w.Units.Units {
+		if other.Dead() || !unit.Hostile(other) {
+			continue
+		}
+		d := dist(unit, other)
+		if d > minDist {
+			continue
+		}
+		if w.Nav != nil && w.Nav.SegmentBlocked(unit.X, unit.Y, other.X, other.Y) {
+			continue
+		}
+		nearest, minDist = other, d
+	}
+	return nearest, nearest != nil
+}
+
+// distHome returns the distance from the home position to the given unit.
+func (b *Brain) distHome(unit *entity.Unit) float64 {
+	return math.Hypot(unit.X-b.HomeX, unit.Y-b.HomeY)
+}
+
+// dist returns the distance between the ground contact points of the given
+// units.
+func dist(a, b *entity.Unit) float64 {
+	return math.Hypot(b.X-a.X, b.Y-a.Y)
+}
@@ -0,0 +1,135 @@
+package ai_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mewspring/ren/pkg/ai"
+	"github.com/mewspring/ren/pkg/entity"
+	"github.com/mewspring/ren/pkg/sim"
+	"github.com/mewspring/ren/pkg/sim/simtest"
+)
+
+// testConfig returns the behaviour parameters of monsters of tests; guards
+// without wandering, for predictable behaviour.
+func testConfig() ai.Config {
+	cfg, err := ai.Profile("guard")
+	if err != nil {
+		panic(err)
+	}
+	cfg.AggroRadius = 300
+	cfg.LeashRadius = 500
+	cfg.FleeHealth = 0.2
+	return cfg
+}
+
+// spawnMonster adds a monster controlled by a brain of the given behaviour
+// parameters at (x, y) to the world.
+func spawnMonster(w *sim.World, cfg ai.Config, x, y float64) (*entity.Unit, *ai.Brain) {
+	monster := simtest.Spawn(w, simtest.UnitType("monster"), entity.FactionMonster, x, y)
+	b := ai.NewBrain(monster, cfg)
+	w.Controllers = append(w.Controllers, b)
+	return monster, b
+}
+
+// runUntil advances the world until the given condition holds, for at most the
+// given number of ticks. The boolean return value indicates if the condition
+// held.
+func runUntil(w *sim.World, ticks int, cond func() bool) bool {
+	for i := 0; i < ticks; i++ {
+		if cond() {
+			return true
+		}
+		w.Update(sim.Input{})
+	}
+	return cond()
+}
+
+func TestChaseAttack(t *testing.T) {
+	w := simtest.World(2000, 1000)
+	player := simtest.SpawnPlayer(w, simtest.UnitType("hero"), 1000, 500)
+	_, b := spawnMonster(w, testConfig(), 600, 500)
+	// Out of sight; idle.
+	simtest.Run(w, sim.Input{}, 10)
+	if b.State != ai.StateIdle {
+		t.Fatalf("state mismatch; expected %v, got %v", ai.StateIdle, b.State)
+	}
+	// In sight; chase.
+	player.X = 800
+	w.Update(sim.Input{})
+	if b.State != ai.StateChase {
+		t.Fatalf("state mismatch; expected %v, got %v", ai.StateChase, b.State)
+	}
+	// In range; attack.
+	if !runUntil(w, 5*sim.TPS, func() bool { return b.State == ai.StateAttack }) {
+		t.Fatalf("state mismatch; expected %v, got %v", ai.StateAttack, b.State)
+	}
+	simtest.Run(w, sim.Input{}, sim.TPS)
+	if player.HP >= simtest.MaxHP {
+		t.Errorf("expected player damaged by attack, got HP %d", player.HP)
+	}
+}
+
+func TestLeash(t *testing.T) {
+	w := simtest.World(2000, 1000)
+	player := simtest.SpawnPlayer(w, simtest.UnitType("hero"), 800, 500)
+	monster, b := spawnMonster(w, testConfig(), 600, 500)
+	w.Update(sim.Input{})
+	if b.State != ai.StateChase {
+		t.Fatalf("state mismatch; expected %v, got %v", ai.StateChase, b.State)
+	}
+	// The target escapes the leash; return home.
+	player.X = 1200
+	w.Update(sim.Input{})
+	if b.State != ai.StateReturn {
+		t.Fatalf("state mismatch; expected %v, got %v", ai.StateReturn, b.State)
+	}
+	if monster.Target != 0 {
+		t.Errorf("expected attack abandoned, got target %d", monster.Target)
+	}
+	// Back home; idle.
+	if !runUntil(w, 5*sim.TPS, func() bool { return b.State == ai.StateIdle }) {
+		t.Fatalf("state mismatch; expected %v, got %v", ai.StateIdle, b.State)
+	}
+	if d := math.Hypot(monster.X-b.HomeX, monster.Y-b.HomeY); d > 1 {
+		t.Errorf("expected monster at home, got distance %v", d)
+	}
+}
+
+func TestFlee(t *testing.T) {
+	w := simtest.World(2000, 1000)
+	player := simtest.SpawnPlayer(w, simtest.UnitType("hero"), 800, 500)
+	monster, b := spawnMonster(w, testConfig(), 600, 500)
+	monster.HP = simtest.MaxHP / 10
+	w.Update(sim.Input{})
+	if b.State != ai.StateFlee {
+		t.Fatalf("state mismatch; expected %v, got %v", ai.StateFlee, b.State)
+	}
+	before := math.Hypot(monster.X-player.X, monster.Y-player.Y)
+	simtest.Run(w, sim.Input{}, sim.TPS)
+	if after := math.Hypot(monster.X-player.X, monster.Y-player.Y); after <= before {
+		t.Errorf("expected monster fleeing; distance %v before and %v after", before, after)
+	}
+}
+
+func TestRetaliate(t *testing.T) {
+	// A monster hit from outside of its aggro radius attacks the attacker.
+	w := simtest.World(2000, 1000)
+	typ := simtest.UnitType("hero")
+	typ.Stats.Range = 400
+	simtest.SpawnPlayer(w, typ, 1000, 500)
+	cfg := testConfig()
+	cfg.AggroRadius = 100
+	monster, b := spawnMonster(w, cfg, 700, 500)
+	w.Update(sim.Input{Attack: true, TargetX: monster.X, TargetY: monster.Y})
+	if !runUntil(w, sim.TPS, func() bool { return monster.HP < simtest.MaxHP }) {
+		t.Fatalf("expected monster damaged by attack")
+	}
+	w.Update(sim.Input{})
+	if b.State != ai.StateChase {
+		t.Fatalf("state mismatch; expected %v, got %v", ai.StateChase, b.State)
+	}
+	if monster.Target != w.PlayerID {
+		t.Errorf("target mismatch; expected %d, got %d", w.PlayerID, monster.Target)
+	}
+}
@@ -0,0 +1,35 @@
+package ai
+
+import "github.com/pkg/errors"
+
+//go:generate stringer -linecomment -type State
+
+// State specifies the behaviour state of a monster.
+type State uint8
+
+// Behaviour states.
+const (
+	// Idle; standing in place.
+	StateIdle State = iota + 1 // idle
+	// Wandering to a random position within the leash of the home position.
+	StateWander // wander
+	// Chasing a hostile unit along a path.
+	StateChase // chase
+	// Attacking a hostile unit within attack range.
+	StateAttack // attack
+	// Fleeing from hostile units at low health.
+	StateFlee // flee
+	// Returning to the home position (e.g. after the target escaped the leash).
+	StateReturn // return
+)
+
+// ParseState returns the behaviour state of the given state name (e.g.
+// "chase").
+func ParseState(s string) (State, error) {
+	for state := StateIdle; state <= StateReturn; state++ {
+		if state.String() == s {
+			return state, nil
+		}
+	}
+	return 0, errors.Errorf("invalid behaviour state %q", s)
+}
@@ -0,0 +1,29 @@
+// Code generated by "stringer -linecomment -type State"; DO NOT EDIT.
+
+package ai
+
+import "strconv"
+
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[StateIdle-1]
+	_ = x[StateWander-2]
+	_ = x[StateChase-3]
+	_ = x[StateAttack-4]
+	_ = x[StateFlee-5]
+	_ = x[StateReturn-6]
+}
+
+const _State_name = "idlewanderchaseattackfleereturn"
+
+var _State_index = [...]uint8{0, 4, 10, 15, 21, 25, 31}
+
+func (i State) String() string {
+	idx := int(i) - 1
+	if i < 1 || idx >= len(_State_index)-1 {
+		return "State(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _State_name[_State_index[idx]:_State_index[idx+1]]
+}
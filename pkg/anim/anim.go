@@ -0,0 +1,155 @@
+package anim
+
+import (
+	"fmt"
+	"time"
+)
+
+// Anim is a graphics animation.
+type Anim struct {
+	// First frame number of graphics animation in sprite sheet.
+	FirstFrame int
+	// Number of frames in graphics animation.
+	NFrames int
+	// Duration of graphics animation (e.g. 300ms).
+	Dur time.Duration
+	// Animation type (e.g. play once, loop, back-and-forth).
+	AnimType AnimType
+	// Frame number at which the action of the animation takes effect (e.g. the
+	// swing connects or the projectile is released); or 0 if none.
+	ActiveFrame int
+	// Specifies whether the active frame was reached during the last update.
+	Activated bool
+	// Anim frame number increment (+1 or -1). Used by back-and-forth animation
+	// to determine direction of animation sequence.
+	Inc int
+	// Current frame.
+	CurFrame int
+	// Time elapsed since last frame update.
+	Elapsed time.Duration
+}
+
+//go:generate stringer -linecomment -type AnimType
+
+// AnimType specifies an animation type.
+type AnimType uint8
+
+// Animation types.
+const (
+	// Play once from first to last frame.
+	//
+	//    0, 1, 2, 3, 4, 5
+	AnimTypeOnce AnimType = iota + 1 // play_once
+	// Play in a loop.
+	//
+	//    0, 1, 2, 3, 4, 5,
+	//    0, 1, 2, 3, 4, 5,
+	//    ...
+	AnimTypeLoop // looped
+	// Play in a loop, back-and-forth.
+	//
+	//    0, 1, 2, 3, 4, 5,
+	//       4, 3, 2, 1,
+	//    0, 1, 2, 3, 4, 5,
+	//    ...
+	AnimTypeBackForth // back_forth
+	// Show still image.
+	//
+	//    0,
+	//    0,
+	//    ...
+	AnimTypeStill // still
+)
+
+// Reset restarts the animation from its first frame.
+func (anim *Anim) Reset() {
+	anim.CurFrame = 0
+	anim.Inc = 1
+	anim.Elapsed = 0
+	anim.Activated = false
+}
+
+// Update advances the animation by the given duration, updating the current
+// frame number once for each frame duration that has passed since the last
+// frame update. The boolean return value indicates that a frame update took
+// place.
+func (anim *Anim) Update(dt time.Duration) bool {
+	anim.Activated = false
+	durPerFrame := anim.Dur / time.Duration(anim.NFrames)
+	if durPerFrame <= 0 {
+		anim.step()
+		anim.checkActive()
+		return true
+	}
+	anim.Elapsed += dt
+	updated := false
+	for anim.Elapsed >= durPerFrame {
+		anim.Elapsed -= durPerFrame
+		anim.step()
+		anim.checkActive()
+		updated = true
+	}
+	return updated
+}
+
+// checkActive records whether the current frame is the active frame of the
+// animation.
+func (anim *Anim) checkActive() {
+	if anim.ActiveFrame > 0 && anim.CurFrame == anim.ActiveFrame {
+		anim.Activated = true
+	}
+}
+
+// step steps to the next frame of the animation.
+func (anim *Anim) step() {
+	switch anim.AnimType {
+	case AnimTypeOnce:
+		anim.CurFrame++
+	case AnimTypeLoop:
+		anim.CurFrame++
+		if anim.CurFrame >= anim.NFrames {
+			anim.CurFrame = 0
+		}
+	case AnimTypeBackForth:
+		anim.CurFrame += anim.Inc
+		switch {
+		case anim.Inc < 0:
+			// back
+			if anim.CurFrame < 0 {
+				anim.CurFrame = 1 // TODO: start at 1?
+				anim.Inc = 1
+			}
+		case anim.Inc > 0:
+			// forth
+			if anim.CurFrame >= anim.NFrames {
+				anim.CurFrame = anim.NFrames - 2 // TODO: start at anim.NFrames - 2?
+				anim.Inc = -1
+			}
+		default:
+			// inc == 0
+			panic(fmt.Errorf("invalid increment for back-forth animation mode; expected +1 or -1, got %d", anim.Inc))
+		}
+	case AnimTypeStill:
+		// keep current frame as is.
+	default:
+		panic(fmt.Errorf("support for animation type %v not yet implemented", anim.AnimType))
+	}
+}
+
+// FrameNum returns the current frame number. The boolean return value indicates
+// if the animation is still playing.
+func (anim *Anim) FrameNum() (int, bool) {
+	switch anim.AnimType {
+	case AnimTypeOnce:
+		if anim.CurFrame >= anim.NFrames {
+			return 0, false
+		}
+	case AnimTypeLoop:
+	case AnimTypeBackForth:
+	case AnimTypeStill:
+		// keep current frame as is.
+	default:
+		panic(fmt.Errorf("support for animation type %v not yet implemented", anim.AnimType))
+	}
+	return anim.CurFrame, true
+}
@@ -0,0 +1,27 @@
+// Code generated by "stringer -linecomment -type AnimType"; DO NOT EDIT.
+
+package anim
+
+import "strconv"
+
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[AnimTypeOnce-1]
+	_ = x[AnimTypeLoop-2]
+	_ = x[AnimTypeBackForth-3]
+	_ = x[AnimTypeStill-4]
+}
+
+const _AnimType_name = "play_onceloopedback_forthstill"
+
+var _AnimType_index = [...]uint8{0, 9, 15, 25, 30}
+
+func (i AnimType) String() string {
+	idx := int(i) - 1
+	if i < 1 || idx >= len(_AnimType_index)-1 {
+		return "AnimType(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _AnimType_name[_AnimType_index[idx]:_AnimType_index[idx+1]]
+}
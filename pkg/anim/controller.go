@@ -0,0 +1,127 @@
+package anim
+
+import "time"
+
+// Controller controls the animation state of a unit, switching between the
+// animations of its sprite sheet.
+//
+// Play once animations (e.g. swing, hit) return to the stance animation when
+// they end, and the die animation is terminal; once dead, the unit remains on
+// the last frame of its death animation.
+type Controller struct {
+	// Graphics animations of the unit, indexed by animation state.
+	Anims map[State]*Anim
+	// Current animation state.
+	State State
+	// Animation state whose active frame was reached during the last update;
+	// valid if activated is set.
+	activeState State
+	// Specifies whether the active frame of an animation was reached during the
+	// last update.
+	activated bool
+}
+
+// NewController returns a new animation controller for the animations of the
+// given sprite sheet, starting in the stance animation state.
+func (sheet *Sheet) NewController() *Controller {
+	c := &Controller{
+		Anims: make(map[State]*Anim),
+		State: StateStance,
+	}
+	for state, def := range sheet.Defs {
+		if def.NFrames == 0 {
+			// skip animations without frames.
+			continue
+		}
+		c.Anims[state] = def.NewAnim()
+	}
+	return c
+}
+
+// Play switches to the given animation state. Switching to the current
+// animation state is a no-op while its animation is still playing. The boolean
+// return value indicates if the controller is in the given animation state;
+// which is not the case if the unit is dead or lacks an animation for the
+// state.
+func (c *Controller) Play(state State) bool {
+	if c.State == StateDie {
+		return state == StateDie
+	}
+	anim, ok := c.Anims[state]
+	if !ok {
+		return false
+	}
+	if state == c.State {
+		if _, playing := anim.FrameNum(); playing {
+			return true
+		}
+	}
+	c.State = state
+	anim.Reset()
+	return true
+}
+
+// Update advances the active animation by the given duration. When a play once
+// animation ends, the controller returns to the stance animation state. The
+// boolean return value indicates that a frame update took place.
+func (c *Controller) Update(dt time.Duration) bool {
+	c.activated = false
+	anim, ok := c.Anims[c.State]
+	if !ok {
+		return false
+	}
+	if c.Done() {
+		// keep last frame of death animation.
+		return false
+	}
+	updated := anim.Update(dt)
+	if anim.Activated {
+		c.activeState = c.State
+		c.activated = true
+	}
+	if _, playing := anim.FrameNum(); !playing && c.State != StateDie {
+		c.State = StateStance
+		if stance, ok := c.Anims[StateStance]; ok {
+			stance.Reset()
+		}
+	}
+	return updated
+}
+
+// Activated reports whether the active frame of the animation of the given
+// state was reached during the last update (e.g. the swing connects).
+func (c *Controller) Activated(state State) bool {
+	return c.activated && c.activeState == state
+}
+
+// Done reports whether the unit has finished its death animation.
+func (c *Controller) Done() bool {
+	if c.State != StateDie {
+		return false
+	}
+	anim, ok := c.Anims[StateDie]
+	if !ok {
+		return true
+	}
+	_, playing := anim.FrameNum()
+	return !playing
+}
+
+// Anim returns the graphics animation of the current animation state.
+func (c *Controller) Anim() (*Anim, bool) {
+	anim, ok := c.Anims[c.State]
+	return anim, ok
+}
+
+// FrameNum returns the current frame number of the active animation. The
+// boolean return value indicates if there is a frame to display.
+func (c *Controller) FrameNum() (int, bool) {
+	anim, ok := c.Anims[c.State]
+	if !ok {
+		return 0, false
+	}
+	if c.Done() {
+		return anim.NFrames - 1, true
+	}
+	return anim.FrameNum()
+}
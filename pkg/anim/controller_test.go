@@ -0,0 +1,124 @@
+package anim_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mewspring/ren/pkg/anim"
+)
+
+// Duration per frame of test animations.
+const durPerFrame = 50 * time.Millisecond
+
+func TestPlayOnce(t *testing.T) {
+	// Play once animations do not restart while playing.
+	c := testSheet().NewController()
+	if !c.Play(anim.StateSwing) {
+		t.Fatalf("unable to play %v animation", anim.StateSwing)
+	}
+	c.Update(durPerFrame)
+	if !c.Play(anim.StateSwing) {
+		t.Fatalf("unable to play %v animation", anim.StateSwing)
+	}
+	if frame, _ := c.FrameNum(); frame != 1 {
+		t.Errorf("frame mismatch of replayed animation; expected 1, got %d", frame)
+	}
+	// They return to the stance animation when they end, after which they may be
+	// played again.
+	c.Update(3 * durPerFrame)
+	if c.State != anim.StateStance {
+		t.Errorf("animation state mismatch after end of animation; expected %v, got %v", anim.StateStance, c.State)
+	}
+	c.Play(anim.StateSwing)
+	if frame, _ := c.FrameNum(); c.State != anim.StateSwing || frame != 0 {
+		t.Errorf("animation mismatch of replayed animation; expected %v frame 0, got %v frame %d", anim.StateSwing, c.State, frame)
+	}
+}
+
+func TestPlayDie(t *testing.T) {
+	c := testSheet().NewController()
+	c.Play(anim.StateSwing)
+	if !c.Play(anim.StateDie) {
+		t.Fatalf("unable to play %v animation", anim.StateDie)
+	}
+	// The die animation cannot be interrupted.
+	for _, state := range []anim.State{anim.StateStance, anim.StateRun, anim.StateSwing, anim.StateHit} {
+		if c.Play(state) {
+			t.Errorf("expected %v animation to not interrupt %v animation", state, anim.StateDie)
+		}
+		if c.State != anim.StateDie {
+			t.Errorf("animation state mismatch; expected %v, got %v", anim.StateDie, c.State)
+		}
+	}
+	// Units are done once their death animation ends, and remain on its last
+	// frame.
+	for i := 0; i < 3; i++ {
+		if c.Done() {
+			t.Fatalf("expected death animation playing at frame %d", i)
+		}
+		c.Update(durPerFrame)
+	}
+	if !c.Done() {
+		t.Fatalf("expected death animation done")
+	}
+	c.Update(10 * durPerFrame)
+	if frame, ok := c.FrameNum(); !ok || frame != 2 || c.State != anim.StateDie {
+		t.Errorf("animation mismatch of dead unit; expected %v frame 2, got %v frame %d", anim.StateDie, c.State, frame)
+	}
+}
+
+func TestActivated(t *testing.T) {
+	golden := []struct {
+		name string
+		// Duration of each update.
+		dt time.Duration
+		// Number of updates.
+		n int
+		// Update at which the swing connects.
+		want int
+	}{
+		{name: "frame by frame", dt: durPerFrame, n: 6, want: 1},
+		{name: "half frames", dt: durPerFrame / 2, n: 12, want: 3},
+		// Updates spanning several frames do not skip the active frame.
+		{name: "skipped frames", dt: 3 * durPerFrame, n: 2, want: 0},
+		{name: "whole animation", dt: 10 * durPerFrame, n: 2, want: 0},
+	}
+	for _, g := range golden {
+		c := testSheet().NewController()
+		c.Play(anim.StateSwing)
+		var got []int
+		for i := 0; i < g.n; i++ {
+			c.Update(g.dt)
+			if c.Activated(anim.StateShoot) {
+				t.Errorf("%s: unexpected activation of %v animation", g.name, anim.StateShoot)
+			}
+			if c.Activated(anim.StateSwing) {
+				got = append(got, i)
+			}
+		}
+		// The active frame is reached exactly once.
+		if len(got) != 1 || got[0] != g.want {
+			t.Errorf("%s: activation mismatch; expected at update %d, got at updates %v", g.name, g.want, got)
+		}
+		if c.State != anim.StateStance {
+			t.Errorf("%s: animation state mismatch after end of animation; expected %v, got %v", g.name, anim.StateStance, c.State)
+		}
+	}
+}
+
+// testSheet returns a sprite sheet of test animations; a looped stance and run
+// animation of 2 frames, a swing animation of 4 frames connecting at frame 2,
+// a hit animation of 1 frame and a death animation of 3 frames.
+func testSheet() *anim.Sheet {
+	return &anim.Sheet{
+		FrameWidth:  8,
+		FrameHeight: 8,
+		Defs: map[anim.State]anim.Def{
+			anim.StateStance: {FirstFrame: 0, NFrames: 2, Dur: 2 * durPerFrame, AnimType: anim.AnimTypeLoop},
+			anim.StateRun:    {FirstFrame: 2, NFrames: 2, Dur: 2 * durPerFrame, AnimType: anim.AnimTypeLoop},
+			anim.StateSwing:  {FirstFrame: 4, NFrames: 4, Dur: 4 * durPerFrame, AnimType: anim.AnimTypeOnce, ActiveFrame: 2},
+			anim.StateHit:    {FirstFrame: 8, NFrames: 1, Dur: durPerFrame, AnimType: anim.AnimTypeOnce},
+			anim.StateDie:    {FirstFrame: 9, NFrames: 3, Dur: 3 * durPerFrame, AnimType: anim.AnimTypeOnce},
+		},
+	}
+}
@@ -0,0 +1,97 @@
+package anim
+
+import (
+	"image"
+	"time"
+
+	"github.com/mewspring/ren/pkg/facing"
+)
+
+// Sheet is a sprite sheet of unit graphics, as described by a Flare animation
+// definition (e.g. animations/balrog.txt). Each row of the sprite sheet holds
+// the frames of one direction, and each animation occupies a consecutive range
+// of columns.
+type Sheet struct {
+	// Frame width in pixels (render_size).
+	FrameWidth int
+	// Frame height in pixels (render_size).
+	FrameHeight int
+	// Offset from the top-left corner of a frame to the ground contact point of
+	// the unit (render_offset).
+	OffsetX, OffsetY int
+	// Facing direction of each row of the sprite sheet; nil for the Flare
+	// layout.
+	Layout *facing.Layout
+	// Animation definitions, indexed by animation state.
+	Defs map[State]Def
+}
+
+// Def is an animation definition of a sprite sheet.
+type Def struct {
+	// First frame number of animation in sprite sheet (position).
+	FirstFrame int
+	// Number of frames in animation (frames).
+	NFrames int
+	// Duration of animation (duration).
+	Dur time.Duration
+	// Animation type (type).
+	AnimType AnimType
+	// Frame number at which the action of the animation takes effect, relative
+	// to the first frame of the animation (active_frame); or 0 if none.
+	ActiveFrame int
+}
+
+// NewAnim returns a new graphics animation based on the given animation
+// definition.
+func (def Def) NewAnim() *Anim {
+	anim := &Anim{
+		FirstFrame:  def.FirstFrame,
+		NFrames:     def.NFrames,
+		Dur:         def.Dur,
+		AnimType:    def.AnimType,
+		ActiveFrame: def.ActiveFrame,
+	}
+	anim.Reset()
+	return anim
+}
+
+// FrameRect returns the bounds of the given frame of the animation definition
+// within the specified row of the sprite sheet.
+func (sheet *Sheet) FrameRect(def Def, row, frame int) image.Rectangle {
+	x := (def.FirstFrame + frame) * sheet.FrameWidth
+	y := row * sheet.FrameHeight
+	return image.Rect(x, y, x+sheet.FrameWidth, y+sheet.FrameHeight)
+}
+
+// Row returns the row of the sprite sheet holding the frames of the given
+// facing direction.
+func (sheet *Sheet) Row(dir facing.Dir) int {
+	if sheet.Layout != nil {
+		return sheet.Layout.Row(dir)
+	}
+	return facing.FlareLayout.Row(dir)
+}
+
+// Clip clips the animation definitions of the sprite sheet to the frames
+// present in a sprite sheet image of the given bounds, keeping the duration per
+// frame; animations without frames in the image are disabled.
+func (sheet *Sheet) Clip(bounds image.Rectangle) {
+	ncols := bounds.Dx() / sheet.FrameWidth
+	for state, def := range sheet.Defs {
+		if def.FirstFrame+def.NFrames <= ncols {
+			continue
+		}
+		n := ncols - def.FirstFrame
+		if n < 0 {
+			n = 0
+		}
+		if def.NFrames > 0 {
+			def.Dur = def.Dur * time.Duration(n) / time.Duration(def.NFrames)
+		}
+		def.NFrames = n
+		if def.ActiveFrame >= n {
+			def.ActiveFrame = 0
+		}
+		sheet.Defs[state] = def
+	}
+}
@@ -0,0 +1,34 @@
+package anim
+
+import "fmt"
+
+//go:generate stringer -linecomment -type State
+
+// State specifies the animation state of a unit.
+type State uint8
+
+// Animation states, as named by Flare animation definitions.
+const (
+	// Idle stance.
+	StateStance State = iota + 1 // stance
+	// Running.
+	StateRun // run
+	// Melee attack.
+	StateSwing // swing
+	// Hit reaction.
+	StateHit // hit
+	// Death.
+	StateDie // die
+	// Ranged attack.
+	StateShoot // shoot
+)
+
+// ParseState returns the animation state of the given state name (e.g. "run").
+func ParseState(s string) (State, error) {
+	for state := StateStance; state <= StateShoot; state++ {
+		if state.String() == s {
+			return state, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid animation state %q", s)
+}
@@ -0,0 +1,29 @@
+// Code generated by "stringer -linecomment -type State"; DO NOT EDIT.
+
+package anim
+
+import "strconv"
+
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[StateStance-1]
+	_ = x[StateRun-2]
+	_ = x[StateSwing-3]
+	_ = x[StateHit-4]
+	_ = x[StateDie-5]
+	_ = x[StateShoot-6]
+}
+
+const _State_name = "stancerunswinghitdieshoot"
+
+var _State_index = [...]uint8{0, 6, 9, 14, 17, 20, 25}
+
+func (i State) String() string {
+	idx := int(i) - 1
+	if i < 1 || idx >= len(_State_index)-1 {
+		return "State(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _State_name[_State_index[idx]:_State_index[idx+1]]
+}
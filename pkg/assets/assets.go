@@ -3,6 +3,7 @@ package assets
 import (
 	"fmt"
 	"image"
+	"os"
 	"path/filepath"
 
 	"github.com/mewkiz/pkg/imgutil"
@@ -35,6 +36,9 @@ type Area struct {
 	Name string
 	// Background layer.
 	BackgroundLayer image.Image
+	// Background (small) layer; a thumbnail of the background layer, or nil if
+	// not present.
+	ThumbLayer image.Image
 	// Normal layer.
 	NormalLayer image.Image
 	// Height layer.
@@ -42,12 +46,24 @@ type Area struct {
 	// AS layer.
 	// TODO: figure out what AS is used for.
 	ASLayer image.Image
+	// Layer file names relative to the assets directory, overriding the default
+	// file names of layers (e.g. "yenwood_background.png"); indexed by layer
+	// kind.
+	LayerFiles map[LayerKind]string
 }
 
 // LoadArea loads the graphics layers of the given area.
 func LoadArea(name string) (*Area, error) {
+	return LoadAreaLayers(name, nil)
+}
+
+// LoadAreaLayers loads the graphics layers of the given area, using the given
+// layer file names relative to the assets directory, indexed by layer kind.
+// Layers not present in files are loaded from their default file names.
+func LoadAreaLayers(name string, files map[LayerKind]string) (*Area, error) {
 	area := &Area{
-		Name: name,
+		Name:       name,
+		LayerFiles: files,
 	}
 	// Background layer.
 	backgroundLayerPath := FullPath(area.layerFileName(LayerKindBackground))
@@ -56,6 +72,15 @@ func LoadArea(name string) (*Area, error) {
 		return nil, errors.WithStack(err)
 	}
 	area.BackgroundLayer = backgroundLayer
+	// Background (small) layer; optional.
+	thumbLayerPath := FullPath(area.layerFileName(LayerKindBackgroundSmall))
+	if _, err := os.Stat(thumbLayerPath); err == nil {
+		thumbLayer, err := imgutil.ReadFile(thumbLayerPath)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		area.ThumbLayer = thumbLayer
+	}
 	// Normal layer.
 	normalLayerPath := FullPath(area.layerFileName(LayerKindNormal))
 	normalLayer, err := imgutil.ReadFile(normalLayerPath)
@@ -80,9 +105,18 @@ func LoadArea(name string) (*Area, error) {
 	return area, nil
 }
 
+// LayerPath returns the full path to the specified layer asset of the given
+// area.
+func (a *Area) LayerPath(kind LayerKind) string {
+	return FullPath(a.layerFileName(kind))
+}
+
 // layerFileName returns the file name of the specified layer for the given
 // area.
 func (a *Area) layerFileName(kind LayerKind) string {
+	if fileName, ok := a.LayerFiles[kind]; ok {
+		return fileName
+	}
 	return fmt.Sprintf("%s_%s.png", a.Name, LayerKindName(kind))
 }
 
@@ -101,8 +135,20 @@ func LayerKindName(kind LayerKind) string {
 	panic(fmt.Errorf("support for layer kind %v not yet implemented", kind))
 }
 
-// AssetsDir specifies the game assets directory.
-const AssetsDir = "_assets_"
+// ParseLayerKind returns the layer kind of the given layer name (e.g.
+// "background").
+func ParseLayerKind(name string) (LayerKind, error) {
+	for kind := LayerKindBackground; kind <= LayerKindAS; kind++ {
+		if LayerKindName(kind) == name {
+			return kind, nil
+		}
+	}
+	return 0, errors.Errorf("invalid layer name %q", name)
+}
+
+// AssetsDir specifies the game assets directory; may be changed before loading
+// assets (e.g. by command line flag).
+var AssetsDir = "_assets_"
 
 // FullPath returns the full path to the specified game asset.
 func FullPath(relPath string) string {
@@ -0,0 +1,113 @@
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+// ChunkLayout is the layout of the chunks of a map area, from which its layers
+// were stitched by dump_layers. Chunk rows are stitched bottom-up; row 0 is at
+// the bottom of the map area.
+type ChunkLayout struct {
+	// Number of chunk rows and columns.
+	NRows int `json:"nrows"`
+	NCols int `json:"ncols"`
+	// Widths of chunk columns in pixels of the background layer, indexed by
+	// column number.
+	ColWidths []int `json:"col_widths"`
+	// Heights of chunk rows in pixels of the background layer, indexed by row
+	// number.
+	RowHeights []int `json:"row_heights"`
+}
+
+// UniformChunkLayout returns a chunk layout dividing the given bounds evenly
+// into the specified number of chunk rows and columns; an approximation of the
+// chunk layout of map areas stitched without chunk layout file.
+func UniformChunkLayout(bounds image.Rectangle, nrows, ncols int) *ChunkLayout {
+	l := &ChunkLayout{
+		NRows:      nrows,
+		NCols:      ncols,
+		ColWidths:  make([]int, ncols),
+		RowHeights: make([]int, nrows),
+	}
+	for col := range l.ColWidths {
+		l.ColWidths[col] = bounds.Dx()*(col+1)/ncols - bounds.Dx()*col/ncols
+	}
+	for row := range l.RowHeights {
+		l.RowHeights[row] = bounds.Dy()*(row+1)/nrows - bounds.Dy()*row/nrows
+	}
+	return l
+}
+
+// ChunkName returns the name of the chunk at the given row and column (e.g.
+// "R000_C000").
+func ChunkName(row, col int) string {
+	return fmt.Sprintf("R%03d_C%03d", row, col)
+}
+
+// ChunkRect returns the bounds of the chunk at the given row and column, in
+// pixels of the background layer.
+func (l *ChunkLayout) ChunkRect(row, col int) image.Rectangle {
+	x := 0
+	for c := 0; c < col; c++ {
+		x += l.ColWidths[c]
+	}
+	y := 0
+	for r := l.NRows - 1; r > row; r-- {
+		y += l.RowHeights[r]
+	}
+	return image.Rect(x, y, x+l.ColWidths[col], y+l.RowHeights[row])
+}
+
+// ChunkAt returns the row and column of the chunk containing the given
+// position, in pixels of the background layer. The boolean return value
+// indicates if such a chunk exists.
+func (l *ChunkLayout) ChunkAt(x, y int) (row, col int, ok bool) {
+	for row := 0; row < l.NRows; row++ {
+		for col := 0; col < l.NCols; col++ {
+			if image.Pt(x, y).In(l.ChunkRect(row, col)) {
+				return row, col, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// ChunkLayoutPath returns the path to the chunk layout file of the given map
+// area.
+func ChunkLayoutPath(areaName string) string {
+	return FullPath(fmt.Sprintf("%s_chunks.json", areaName))
+}
+
+// LoadChunkLayout loads the chunk layout of the given map area.
+func LoadChunkLayout(areaName string) (*ChunkLayout, error) {
+	path := ChunkLayoutPath(areaName)
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	l := &ChunkLayout{}
+	if err := json.Unmarshal(buf, l); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse chunk layout %q", path)
+	}
+	if len(l.ColWidths) != l.NCols || len(l.RowHeights) != l.NRows {
+		return nil, errors.Errorf("invalid chunk layout %q; size mismatch between chunk dimensions and number of rows or columns", path)
+	}
+	return l, nil
+}
+
+// Write writes the chunk layout to the given JSON file.
+func (l *ChunkLayout) Write(path string) error {
+	buf, err := json.MarshalIndent(l, "", "\t")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := ioutil.WriteFile(path, buf, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package assets_test
+
+import (
+	"image"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/mewspring/ren/pkg/assets"
+)
+
+// testLayout returns a chunk layout of 2 rows and 3 columns, of which the last
+// column and the top row are cut short by the edges of the map area.
+func testLayout() *assets.ChunkLayout {
+	return &assets.ChunkLayout{
+		NRows:      2,
+		NCols:      3,
+		ColWidths:  []int{100, 100, 80},
+		RowHeights: []int{64, 50},
+	}
+}
+
+func TestChunkRect(t *testing.T) {
+	l := testLayout()
+	golden := []struct {
+		row, col int
+		want     image.Rectangle
+	}{
+		// Row 0 is at the bottom of the map area.
+		{row: 0, col: 0, want: image.Rect(0, 50, 100, 114)},
+		{row: 0, col: 2, want: image.Rect(200, 50, 280, 114)},
+		{row: 1, col: 0, want: image.Rect(0, 0, 100, 50)},
+		{row: 1, col: 2, want: image.Rect(200, 0, 280, 50)},
+	}
+	for _, g := range golden {
+		if got := l.ChunkRect(g.row, g.col); got != g.want {
+			t.Errorf("%s: chunk bounds mismatch; expected %v, got %v", assets.ChunkName(g.row, g.col), g.want, got)
+		}
+	}
+}
+
+func TestChunkAt(t *testing.T) {
+	l := testLayout()
+	golden := []struct {
+		x, y     int
+		row, col int
+		ok       bool
+	}{
+		{x: 0, y: 0, row: 1, col: 0, ok: true},
+		{x: 0, y: 49, row: 1, col: 0, ok: true},
+		{x: 0, y: 50, row: 0, col: 0, ok: true},
+		{x: 279, y: 113, row: 0, col: 2, ok: true},
+		{x: 200, y: 50, row: 0, col: 2, ok: true},
+		{x: 199, y: 49, row: 1, col: 1, ok: true},
+		// Positions outside of the map area.
+		{x: 280, y: 0},
+		{x: 0, y: 114},
+		{x: -1, y: 0},
+	}
+	for _, g := range golden {
+		row, col, ok := l.ChunkAt(g.x, g.y)
+		if ok != g.ok || row != g.row || col != g.col {
+			t.Errorf("chunk mismatch at (%d, %d); expected row %d, col %d (%v), got row %d, col %d (%v)", g.x, g.y, g.row, g.col, g.ok, row, col, ok)
+		}
+	}
+}
+
+func TestUniformChunkLayout(t *testing.T) {
+	// Chunk rectangles cover the bounds without gaps or overlap, also if the
+	// bounds are not evenly divisible.
+	bounds := image.Rect(0, 0, 301, 199)
+	l := assets.UniformChunkLayout(bounds, 3, 4)
+	if want := []int{75, 75, 75, 76}; !reflect.DeepEqual(l.ColWidths, want) {
+		t.Errorf("column widths mismatch; expected %v, got %v", want, l.ColWidths)
+	}
+	if want := []int{66, 66, 67}; !reflect.DeepEqual(l.RowHeights, want) {
+		t.Errorf("row heights mismatch; expected %v, got %v", want, l.RowHeights)
+	}
+	area := 0
+	var union image.Rectangle
+	for row := 0; row < l.NRows; row++ {
+		for col := 0; col < l.NCols; col++ {
+			r := l.ChunkRect(row, col)
+			area += r.Dx() * r.Dy()
+			union = union.Union(r)
+		}
+	}
+	if union != bounds || area != bounds.Dx()*bounds.Dy() {
+		t.Errorf("chunk coverage mismatch; expected %v of %d pixels, got %v of %d pixels", bounds, bounds.Dx()*bounds.Dy(), union, area)
+	}
+}
+
+func TestLoadChunkLayout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chunks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	oldDir := assets.AssetsDir
+	assets.AssetsDir = dir
+	defer func() { assets.AssetsDir = oldDir }()
+
+	// Chunk layouts written by dump_layers are loaded as written.
+	want := testLayout()
+	if err := want.Write(assets.ChunkLayoutPath("test")); err != nil {
+		t.Fatalf("unable to write chunk layout; %+v", err)
+	}
+	got, err := assets.LoadChunkLayout("test")
+	if err != nil {
+		t.Fatalf("unable to load chunk layout; %+v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("chunk layout mismatch; expected %+v, got %+v", want, got)
+	}
+
+	// Chunk layouts whose dimensions disagree with the number of rows or
+	// columns are rejected.
+	invalid := testLayout()
+	invalid.NCols = 4
+	if err := invalid.Write(assets.ChunkLayoutPath("invalid")); err != nil {
+		t.Fatalf("unable to write chunk layout; %+v", err)
+	}
+	if _, err := assets.LoadChunkLayout("invalid"); err == nil {
+		t.Errorf("expected error loading invalid chunk layout")
+	}
+}
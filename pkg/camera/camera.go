@@ -0,0 +1,142 @@
+package camera
+
+import (
+	"image"
+	"math"
+	"time"
+)
+
+// Camera is a scrolling camera, mapping world (area pixel) coordinates to
+// screen coordinates.
+type Camera struct {
+	// Position of the center of the view, in world coordinates.
+	X, Y float64
+	// Zoom factor; screen pixels per world pixel.
+	Zoom float64
+	// Minimum and maximum zoom factor.
+	MinZoom, MaxZoom float64
+	// Size of the viewport in screen pixels.
+	Width, Height int
+	// Bounds of the map area in world coordinates; or empty if unbounded.
+	Bounds image.Rectangle
+	// Follow rate of the camera; the fraction of the distance to the followed
+	// target covered per second is 1 - e^-FollowRate.
+	FollowRate float64
+}
+
+// New returns a new camera with a viewport of the given size, clamped to the
+// given map area bounds and centered on the area.
+func New(width, height int, bounds image.Rectangle) *Camera {
+	c := &Camera{
+		Zoom:       1,
+		MinZoom:    0.25,
+		MaxZoom:    2,
+		Width:      width,
+		Height:     height,
+		Bounds:     bounds,
+		FollowRate: 5,
+	}
+	c.X = float64(bounds.Min.X+bounds.Max.X) / 2
+	c.Y = float64(bounds.Min.Y+bounds.Max.Y) / 2
+	c.Clamp()
+	return c
+}
+
+// WorldToScreen converts the given world coordinates to screen coordinates.
+func (c *Camera) WorldToScreen(x, y float64) (sx, sy float64) {
+	sx = (x-c.X)*c.Zoom + float64(c.Width)/2
+	sy = (y-c.Y)*c.Zoom + float64(c.Height)/2
+	return sx, sy
+}
+
+// ScreenToWorld converts the given screen coordinates to world coordinates.
+func (c *Camera) ScreenToWorld(sx, sy float64) (x, y float64) {
+	x = (sx-float64(c.Width)/2)/c.Zoom + c.X
+	y = (sy-float64(c.Height)/2)/c.Zoom + c.Y
+	return x, y
+}
+
+// Viewport returns the visible region of the world, in world coordinates.
+func (c *Camera) Viewport() image.Rectangle {
+	x0, y0 := c.ScreenToWorld(0, 0)
+	x1, y1 := c.ScreenToWorld(float64(c.Width), float64(c.Height))
+	return image.Rect(int(math.Floor(x0)), int(math.Floor(y0)), int(math.Ceil(x1)), int(math.Ceil(y1)))
+}
+
+// Pan moves the camera by the given distance in screen pixels.
+func (c *Camera) Pan(dx, dy float64) {
+	c.X += dx / c.Zoom
+	c.Y += dy / c.Zoom
+	c.Clamp()
+}
+
+// ZoomAt multiplies the zoom factor by the given factor, keeping the world
+// position under the given screen position fixed on screen.
+func (c *Camera) ZoomAt(factor, sx, sy float64) {
+	x, y := c.ScreenToWorld(sx, sy)
+	c.Zoom = math.Max(c.MinZoom, math.Min(c.MaxZoom, c.Zoom*factor))
+	// Move camera so that (x, y) remains at (sx, sy).
+	c.X = x - (sx-float64(c.Width)/2)/c.Zoom
+	c.Y = y - (sy-float64(c.Height)/2)/c.Zoom
+	c.Clamp()
+}
+
+// Follow moves the camera smoothly towards the given world position, as time
+// dt passes.
+func (c *Camera) Follow(x, y float64, dt time.Duration) {
+	t := 1 - math.Exp(-c.FollowRate*dt.Seconds())
+	c.X += (x - c.X) * t
+	c.Y += (y - c.Y) * t
+	c.Clamp()
+}
+
+// CenterOn centers the camera on the given world position.
+func (c *Camera) CenterOn(x, y float64) {
+	c.X, c.Y = x, y
+	c.Clamp()
+}
+
+// Clamp clamps the camera position so that the viewport stays within the map
+// area bounds. The view is centered on the area along axes in which the area
+// is smaller than the viewport.
+func (c *Camera) Clamp() {
+	if c.Bounds.Empty() {
+		return
+	}
+	halfWidth := float64(c.Width) / 2 / c.Zoom
+	halfHeight := float64(c.Height) / 2 / c.Zoom
+	c.X = clamp(c.X, float64(c.Bounds.Min.X)+halfWidth, float64(c.Bounds.Max.X)-halfWidth)
+	c.Y = clamp(c.Y, float64(c.Bounds.Min.Y)+halfHeight, float64(c.Bounds.Max.Y)-halfHeight)
+}
+
+// clamp clamps v to the range [min, max]; or returns the midpoint of the range
+// if min > max.
+func clamp(v, min, max float64) float64 {
+	if min > max {
+		return (min + max) / 2
+	}
+	return math.Max(min, math.Min(max, v))
+}
+
+// EdgeScroll returns the pan direction (-1, 0 or +1 per axis) for edge
+// scrolling, given the cursor position in screen coordinates and the width of
+// the scroll margin at the edges of the viewport.
+func (c *Camera) EdgeScroll(cx, cy, margin int) (dx, dy float64) {
+	// Ignore cursor outside of viewport (e.g. outside of window).
+	if cx < 0 || cy < 0 || cx >= c.Width || cy >= c.Height {
+		return 0, 0
+	}
+	switch {
+	case cx < margin:
+		dx = -1
+	case cx >= c.Width-margin:
+		dx = +1
+	}
+	switch {
+	case cy < margin:
+		dy = -1
+	case cy >= c.Height-margin:
+		dy = +1
+	}
+	return dx, dy
+}
@@ -0,0 +1,92 @@
+package camera_test
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/mewspring/ren/pkg/camera"
+)
+
+// Viewport size of tests, in screen pixels.
+const (
+	width  = 200
+	height = 100
+)
+
+func TestCenterOn(t *testing.T) {
+	golden := []struct {
+		name   string
+		bounds image.Rectangle
+		zoom   float64
+		// Position to center on.
+		x, y float64
+		// Expected camera position.
+		wantX, wantY float64
+	}{
+		{name: "within bounds", bounds: image.Rect(0, 0, 1000, 500), zoom: 1, x: 300, y: 200, wantX: 300, wantY: 200},
+		{name: "top-left corner", bounds: image.Rect(0, 0, 1000, 500), zoom: 1, x: 0, y: 0, wantX: 100, wantY: 50},
+		{name: "bottom-right corner", bounds: image.Rect(0, 0, 1000, 500), zoom: 1, x: 1000, y: 500, wantX: 900, wantY: 450},
+		{name: "offset bounds", bounds: image.Rect(-500, 100, 500, 600), zoom: 1, x: -1000, y: 1000, wantX: -400, wantY: 550},
+		{name: "zoomed in", bounds: image.Rect(0, 0, 1000, 500), zoom: 2, x: 0, y: 500, wantX: 50, wantY: 475},
+		{name: "zoomed out", bounds: image.Rect(0, 0, 1000, 500), zoom: 0.5, x: 0, y: 500, wantX: 200, wantY: 400},
+		// The view is centered on areas smaller than the viewport.
+		{name: "small area", bounds: image.Rect(0, 0, 150, 80), zoom: 1, x: 0, y: 0, wantX: 75, wantY: 40},
+		{name: "narrow area", bounds: image.Rect(0, 0, 150, 500), zoom: 1, x: 1000, y: 1000, wantX: 75, wantY: 450},
+		{name: "unbounded", zoom: 1, x: -1000, y: 1000, wantX: -1000, wantY: 1000},
+	}
+	for _, g := range golden {
+		c := camera.New(width, height, g.bounds)
+		c.Zoom = g.zoom
+		c.CenterOn(g.x, g.y)
+		if c.X != g.wantX || c.Y != g.wantY {
+			t.Errorf("%s: camera position mismatch; expected (%v, %v), got (%v, %v)", g.name, g.wantX, g.wantY, c.X, c.Y)
+		}
+	}
+}
+
+func TestZoomAt(t *testing.T) {
+	golden := []struct {
+		name string
+		// Zoom factor multiplier.
+		factor float64
+		// Cursor position in screen coordinates.
+		sx, sy float64
+		// Expected zoom factor.
+		want float64
+	}{
+		{name: "zoom in at center", factor: 1.5, sx: width / 2, sy: height / 2, want: 1.5},
+		{name: "zoom in at cursor", factor: 1.5, sx: 20, sy: 80, want: 1.5},
+		{name: "zoom out at cursor", factor: 0.5, sx: 150, sy: 30, want: 0.5},
+		{name: "maximum zoom", factor: 10, sx: 20, sy: 80, want: 2},
+		{name: "minimum zoom", factor: 0.1, sx: 150, sy: 30, want: 0.25},
+	}
+	for _, g := range golden {
+		c := camera.New(width, height, image.Rect(0, 0, 10000, 10000))
+		c.CenterOn(5000, 5000)
+		x, y := c.ScreenToWorld(g.sx, g.sy)
+		c.ZoomAt(g.factor, g.sx, g.sy)
+		if c.Zoom != g.want {
+			t.Errorf("%s: zoom mismatch; expected %v, got %v", g.name, g.want, c.Zoom)
+		}
+		// The world position under the cursor stays under the cursor.
+		if sx, sy := c.WorldToScreen(x, y); math.Abs(sx-g.sx) > 1e-9 || math.Abs(sy-g.sy) > 1e-9 {
+			t.Errorf("%s: cursor position mismatch of (%v, %v); expected (%v, %v), got (%v, %v)", g.name, x, y, g.sx, g.sy, sx, sy)
+		}
+	}
+}
+
+func TestZoomAtClamped(t *testing.T) {
+	// Zooming out at the area edges keeps the viewport within the area.
+	c := camera.New(width, height, image.Rect(0, 0, 600, 300))
+	c.CenterOn(0, 0)
+	c.ZoomAt(0.5, 0, 0)
+	if c.X != 200 || c.Y != 100 {
+		t.Errorf("camera position mismatch; expected (200, 100), got (%v, %v)", c.X, c.Y)
+	}
+	// Areas smaller than the zoomed out viewport are centered.
+	c.ZoomAt(0.5, 0, 0)
+	if c.Zoom != 0.25 || c.X != 300 || c.Y != 150 {
+		t.Errorf("camera mismatch; expected zoom 0.25 at (300, 150), got zoom %v at (%v, %v)", c.Zoom, c.X, c.Y)
+	}
+}
@@ -0,0 +1,89 @@
+package depth
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Default height parameters.
+const (
+	// Default height in pixels of the brightest value of height layers.
+	DefaultMaxHeight = 255
+	// Default height in pixels below which scenery is considered flat ground.
+	DefaultMinHeight = 4
+)
+
+// Map is a per-pixel depth map of a map area, derived from its height layer.
+//
+// The height layer holds the height above the ground of the scenery visible at
+// each pixel of the background layer. Since the pre-rendered background is
+// viewed from above at an angle, scenery at height h seen at (x, y) stands on
+// the ground at (x, y+h); the depth of a pixel is the y-coordinate of its
+// ground point. A unit standing at (ux, uy) is behind the scenery at (x, y) if
+// the depth at (x, y) is greater than uy. Flat ground never occludes units.
+type Map struct {
+	// Height layer of the map area.
+	Height image.Image
+	// Bounds of the background layer; the height layer is stretched to cover
+	// it.
+	Bounds image.Rectangle
+	// Height in pixels of the brightest value of the height layer.
+	MaxHeight float64
+	// Height in pixels below which scenery is considered flat ground.
+	MinHeight float64
+}
+
+// New returns a new depth map based on the given height layer, covering a
+// background layer of the given bounds.
+func New(height image.Image, bounds image.Rectangle) *Map {
+	return &Map{
+		Height:    height,
+		Bounds:    bounds,
+		MaxHeight: DefaultMaxHeight,
+		MinHeight: DefaultMinHeight,
+	}
+}
+
+// HeightAt returns the height in pixels of the scenery at (x, y) in background
+// layer coordinates.
+func (m *Map) HeightAt(x, y int) float64 {
+	if !(image.Point{X: x, Y: y}.In(m.Bounds)) {
+		return 0
+	}
+	hb := m.Height.Bounds()
+	hx := hb.Min.X + (x-m.Bounds.Min.X)*hb.Dx()/m.Bounds.Dx()
+	hy := hb.Min.Y + (y-m.Bounds.Min.Y)*hb.Dy()/m.Bounds.Dy()
+	c := color.Gray16Model.Convert(m.Height.At(hx, hy)).(color.Gray16)
+	return float64(c.Y) / 0xFFFF * m.MaxHeight
+}
+
+// Depth returns the depth of the scenery at (x, y) in background layer
+// coordinates; i.e. the y-coordinate of its ground point.
+func (m *Map) Depth(x, y int) float64 {
+	return float64(y) + m.HeightAt(x, y)
+}
+
+// Occlude stores in dst the given frame of a unit with ground depth d, with the
+// pixels hidden behind scenery cleared. The bounds of dst specify the position
+// of the frame in background layer coordinates.
+func (m *Map) Occlude(dst *image.RGBA, frame image.Image, d float64) {
+	dr := dst.Bounds()
+	draw.Draw(dst, dr, frame, frame.Bounds().Min, draw.Src)
+	for y := dr.Min.Y; y < dr.Max.Y; y++ {
+		// Scenery above the ground point of the unit is never in front of it, as
+		// height is non-negative.
+		if float64(y)+m.MaxHeight <= d {
+			continue
+		}
+		for x := dr.Min.X; x < dr.Max.X; x++ {
+			if dst.RGBAAt(x, y).A == 0 {
+				continue
+			}
+			h := m.HeightAt(x, y)
+			if h >= m.MinHeight && float64(y)+h > d {
+				dst.SetRGBA(x, y, color.RGBA{})
+			}
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package depth_test
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/mewspring/ren/pkg/depth"
+)
+
+func TestOcclude(t *testing.T) {
+	// A 100x100 map area with a pillar 20 pixels high at (40, 30)-(60, 50),
+	// standing on the ground at y = 50 to 70, and a bump at (70, 0)-(80, 100)
+	// below the minimum height of scenery.
+	height := image.NewGray(image.Rect(0, 0, 100, 100))
+	draw.Draw(height, image.Rect(40, 30, 60, 50), image.NewUniform(color.Gray{Y: 20}), image.Point{}, draw.Src)
+	draw.Draw(height, image.Rect(70, 0, 80, 100), image.NewUniform(color.Gray{Y: 3}), image.Point{}, draw.Src)
+	m := depth.New(height, height.Bounds())
+	golden := []struct {
+		name string
+		// Position of the unit frame in background layer coordinates.
+		frame image.Rectangle
+		// Ground depth of the unit.
+		d float64
+		// Pixels of the frame hidden behind scenery.
+		want image.Rectangle
+	}{
+		{name: "in front of pillar", frame: image.Rect(30, 20, 70, 80), d: 80},
+		{name: "behind pillar", frame: image.Rect(30, 20, 70, 60), d: 60, want: image.Rect(40, 41, 60, 50)},
+		{name: "far behind pillar", frame: image.Rect(30, 0, 70, 45), d: 45, want: image.Rect(40, 30, 60, 45)},
+		{name: "behind flat ground", frame: image.Rect(60, 0, 90, 10), d: 10},
+	}
+	for _, g := range golden {
+		// Opaque frame with a transparent first column.
+		frame := image.NewRGBA(image.Rect(0, 0, g.frame.Dx(), g.frame.Dy()))
+		draw.Draw(frame, frame.Bounds(), image.NewUniform(color.RGBA{R: 0xFF, A: 0xFF}), image.Point{}, draw.Src)
+		for y := 0; y < g.frame.Dy(); y++ {
+			frame.SetRGBA(0, y, color.RGBA{})
+		}
+		dst := image.NewRGBA(g.frame)
+		m.Occlude(dst, frame, g.d)
+		for y := g.frame.Min.Y; y < g.frame.Max.Y; y++ {
+			for x := g.frame.Min.X; x < g.frame.Max.X; x++ {
+				p := image.Pt(x, y)
+				want := frame.RGBAAt(x-g.frame.Min.X, y-g.frame.Min.Y)
+				if p.In(g.want) {
+					want = color.RGBA{}
+				}
+				if got := dst.RGBAAt(x, y); got != want {
+					t.Errorf("%s: pixel mismatch at %v; expected %v, got %v", g.name, p, want, got)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package entity
+
+import "time"
+
+// Collection is a collection of units.
+type Collection struct {
+	// Units of the collection, in order of insertion.
+	Units []*Unit
+	// ID of the next unit added to the collection.
+	nextID int
+}
+
+// NewCollection returns a new empty collection of units.
+func NewCollection() *Collection {
+	return &Collection{
+		nextID: 1,
+	}
+}
+
+// Add adds the given unit to the collection, assigning it a unique ID.
+func (c *Collection) Add(unit *Unit) *Unit {
+	unit.ID = c.nextID
+	c.nextID++
+	c.Units = append(c.Units, unit)
+	return unit
+}
+
+// Insert inserts the given unit into the collection, keeping its ID (e.g. when
+// restoring a saved game). IDs of units added later are assigned after the ID
+// of the unit.
+func (c *Collection) Insert(unit *Unit) *Unit {
+	if unit.ID >= c.nextID {
+		c.nextID = unit.ID + 1
+	}
+	c.Units = append(c.Units, unit)
+	return unit
+}
+
+// Remove removes the unit with the given ID from the collection.
+func (c *Collection) Remove(id int) {
+	for i, unit := range c.Units {
+		if unit.ID == id {
+			c.Units = append(c.Units[:i], c.Units[i+1:]...)
+			return
+		}
+	}
+}
+
+// ByID returns the unit with the given ID. The boolean return value indicates
+// if the unit was present in the collection.
+func (c *Collection) ByID(id int) (*Unit, bool) {
+	for _, unit := range c.Units {
+		if unit.ID == id {
+			return unit, true
+		}
+	}
+	return nil, false
+}
+
+// Update advances the animations of the units in the collection by the given
+// duration.
+func (c *Collection) Update(dt time.Duration) {
+	for _, unit := range c.Units {
+		unit.Anim.Update(dt)
+	}
+}
@@ -0,0 +1,100 @@
+package entity
+
+import (
+	"image"
+	"math"
+
+	"github.com/mewspring/ren/pkg/anim"
+	"github.com/mewspring/ren/pkg/facing"
+)
+
+// ProjectileType is a projectile type (e.g. fireball).
+type ProjectileType struct {
+	// Projectile type name.
+	Name string
+	// Source image name of sprite sheet.
+	SheetPath string
+	// Sprite sheet of projectile type; the stance animation is played in
+	// flight.
+	Sheet *anim.Sheet
+	// Speed in pixels per second.
+	Speed float64
+	// Maximum travel distance in pixels.
+	Range float64
+	// Collision radius in pixels.
+	Radius float64
+	// Damage dealt on impact.
+	Damage int
+	// Radius in pixels of area damage on impact; or 0 to only damage the unit
+	// hit.
+	Splash float64
+}
+
+// Projectile is a projectile in flight (e.g. fireball).
+type Projectile struct {
+	// Projectile ID; unique within the world of the projectile.
+	ID int
+	// Projectile type.
+	Type *ProjectileType
+	// ID of unit which fired the projectile.
+	OwnerID int
+	// Faction of unit which fired the projectile; only hostile units are hit.
+	Faction Faction
+	// Position of the ground contact point of the projectile, in area pixel
+	// coordinates.
+	X, Y float64
+	// Unit vector of flight direction.
+	DX, DY float64
+	// Facing direction.
+	Dir facing.Dir
+	// Distance travelled in pixels.
+	Travelled float64
+	// Flight animation.
+	Anim *anim.Anim
+	// Specifies whether the projectile has hit or expired.
+	Done bool
+}
+
+// NewProjectile returns a new projectile of the given type fired by the given
+// unit towards the target position.
+func NewProjectile(typ *ProjectileType, owner *Unit, x, y float64) *Projectile {
+	p := &Projectile{
+		Type:    typ,
+		OwnerID: owner.ID,
+		Faction: owner.Faction,
+		X:       owner.X,
+		Y:       owner.Y,
+		Dir:     owner.Dir,
+		Anim:    typ.Sheet.Defs[anim.StateStance].NewAnim(),
+	}
+	if dx, dy := x-owner.X, y-owner.Y; dx != 0 || dy != 0 {
+		n := math.Hypot(dx, dy)
+		p.DX, p.DY = dx/n, dy/n
+		if dir, ok := facing.FromScreen(dx, dy); ok {
+			p.Dir = dir
+		}
+	} else {
+		p.DX, p.DY = owner.Dir.ScreenVector()
+	}
+	return p
+}
+
+// Frame returns the bounds of the current animation frame of the projectile
+// within its sprite sheet. The boolean return value indicates if there is a
+// frame to display.
+func (p *Projectile) Frame() (image.Rectangle, bool) {
+	frame, ok := p.Anim.FrameNum()
+	if !ok || p.Anim.NFrames == 0 {
+		return image.Rectangle{}, false
+	}
+	sheet := p.Type.Sheet
+	def := sheet.Defs[anim.StateStance]
+	return sheet.FrameRect(def, sheet.Row(p.Dir), frame), true
+}
+
+// DrawPos returns the position of the top-left corner of the frames of the
+// projectile, in area pixel coordinates.
+func (p *Projectile) DrawPos() (x, y float64) {
+	sheet := p.Type.Sheet
+	return p.X - float64(sheet.OffsetX), p.Y - float64(sheet.OffsetY)
+}
@@ -0,0 +1,26 @@
+package entity
+
+// Prop is a static prop of an area (e.g. barrel, crate).
+type Prop struct {
+	// Prop ID; unique within the world of the prop.
+	ID int
+	// Prop name.
+	Name string
+	// Path to prop image, relative to the assets directory.
+	ImagePath string
+	// Position of the ground contact point of the prop, in area pixel
+	// coordinates.
+	X, Y float64
+	// Offset from the top-left corner of the prop image to its ground contact
+	// point.
+	OffsetX, OffsetY int
+	// Radius in pixels around the ground contact point blocking movement; or 0
+	// if not blocking.
+	Radius float64
+}
+
+// DrawPos returns the position of the top-left corner of the prop image, in
+// area pixel coordinates.
+func (prop *Prop) DrawPos() (x, y float64) {
+	return prop.X - float64(prop.OffsetX), prop.Y - float64(prop.OffsetY)
+}
@@ -0,0 +1,240 @@
+package entity
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/mewspring/ren/pkg/anim"
+	"github.com/mewspring/ren/pkg/facing"
+	"github.com/mewspring/ren/pkg/pathfind"
+)
+
+// Type is a unit type (e.g. balrog).
+type Type struct {
+	// Unit type name.
+	Name string
+	// Path to sprite sheet image, relative to the assets directory.
+	SheetPath string
+	// Sprite sheet of unit type.
+	Sheet *anim.Sheet
+	// Base stats of unit type.
+	Stats Stats
+	// Projectile fired by ranged attacks of the unit type; or nil if none.
+	Projectile *ProjectileType
+}
+
+// Stats holds the stats of a unit.
+type Stats struct {
+	// Maximum health.
+	MaxHP int
+	// Movement speed in pixels per second.
+	Speed float64
+	// Damage dealt per attack.
+	Damage int
+	// Attack range in pixels, measured between ground contact points.
+	Range float64
+}
+
+// Faction specifies the faction of a unit. Units of different factions are
+// hostile to each other.
+type Faction uint8
+
+// Factions.
+const (
+	// Units controlled by the player.
+	FactionPlayer Faction = iota
+	// Monsters.
+	FactionMonster
+)
+
+// factionNames maps from faction to faction name.
+var factionNames = map[Faction]string{
+	FactionPlayer:  "player",
+	FactionMonster: "monster",
+}
+
+// String returns the name of the faction (e.g. "player").
+func (faction Faction) String() string {
+	if s, ok := factionNames[faction]; ok {
+		return s
+	}
+	return fmt.Sprintf("Faction(%d)", uint8(faction))
+}
+
+// ParseFaction returns the faction of the given faction name (e.g. "monster").
+func ParseFaction(s string) (Faction, error) {
+	for faction, name := range factionNames {
+		if name == s {
+			return faction, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid faction %q; expected player or monster", s)
+}
+
+// Unit is a unit (e.g. monster) of an area.
+type Unit struct {
+	// Unit ID; unique within the collection of the unit.
+	ID int
+	// Unit type.
+	Type *Type
+	// Position of the ground contact point of the unit, in area pixel
+	// coordinates.
+	X, Y float64
+	// Facing direction.
+	Dir facing.Dir
+	// Animation controller; holds the current animation state.
+	Anim *anim.Controller
+	// Faction of the unit.
+	Faction Faction
+	// Stats of the unit.
+	Stats Stats
+	// Current health.
+	HP int
+	// Remaining waypoints of the path followed by the unit; or nil if not
+	// following a path.
+	Path []pathfind.Point
+	// ID of the unit attacked by the unit; or 0 if none.
+	Target int
+	// Target position of the current ranged attack, in area pixel coordinates.
+	AimX, AimY float64
+	// ID of the unit which last damaged the unit; or 0 if none. Cleared by the
+	// controller of the unit once reacted to (e.g. AI retaliating).
+	Attacker int
+}
+
+// NewUnit returns a new unit of the given type, positioned at (x, y) and facing
+// south.
+func NewUnit(typ *Type, x, y float64) *Unit {
+	return &Unit{
+		Type:  typ,
+		X:     x,
+		Y:     y,
+		Dir:   facing.South,
+		Anim:  typ.Sheet.NewController(),
+		Stats: typ.Stats,
+		HP:    typ.Stats.MaxHP,
+	}
+}
+
+// Move moves the unit by the given screen vector, facing the direction of
+// movement and playing its run animation.
+func (unit *Unit) Move(dx, dy float64) {
+	if !unit.Anim.Play(anim.StateRun) {
+		return
+	}
+	unit.X += dx
+	unit.Y += dy
+	if dir, ok := facing.FromScreen(dx, dy); ok {
+		unit.Dir = dir
+	}
+}
+
+// Idle returns the unit to its stance animation if running.
+func (unit *Unit) Idle() {
+	if unit.Anim.State == anim.StateRun {
+		unit.Anim.Play(anim.StateStance)
+	}
+}
+
+// Attack makes the unit swing at the given target position, facing the target.
+// Attacks are delayed until the hit reaction of the unit has ended.
+func (unit *Unit) Attack(x, y float64) {
+	if unit.Anim.State == anim.StateHit {
+		return
+	}
+	if !unit.Anim.Play(anim.StateSwing) {
+		return
+	}
+	unit.Face(x, y)
+}
+
+// Hostile reports whether the unit is hostile to the other unit.
+func (unit *Unit) Hostile(other *Unit) bool {
+	return unit.Faction != other.Faction
+}
+
+// Dead reports whether the unit is dead.
+func (unit *Unit) Dead() bool {
+	return unit.HP <= 0
+}
+
+// Selectable reports whether the unit may be selected and ordered by the
+// player; i.e. a living unit of the player faction.
+func (unit *Unit) Selectable() bool {
+	return unit.Faction == FactionPlayer && !unit.Dead()
+}
+
+// TakeDamage reduces the health of the unit by the given damage, playing its
+// hit animation; or its die animation if the damage is fatal. The boolean return
+// value indicates if the unit was killed.
+func (unit *Unit) TakeDamage(damage int) bool {
+	if unit.Dead() {
+		return false
+	}
+	unit.HP -= damage
+	if unit.HP > 0 {
+		unit.Anim.Play(anim.StateHit)
+		return false
+	}
+	unit.HP = 0
+	unit.Path = nil
+	unit.Target = 0
+	unit.Anim.Play(anim.StateDie)
+	return true
+}
+
+// Shoot makes the unit play its ranged attack animation at the given target
+// position, facing the target. The projectile is released at the active frame
+// of the animation. The boolean return value indicates if the unit is shooting;
+// which is not the case if the unit lacks a ranged attack.
+func (unit *Unit) Shoot(x, y float64) bool {
+	if unit.Type.Projectile == nil || unit.Anim.State == anim.StateHit {
+		return false
+	}
+	if !unit.Anim.Play(anim.StateShoot) {
+		return false
+	}
+	unit.AimX, unit.AimY = x, y
+	unit.Face(x, y)
+	return true
+}
+
+// SetDead kills the unit instantly, showing the last frame of its death
+// animation (e.g. the corpse of a monster killed on a previous visit of an
+// area).
+func (unit *Unit) SetDead() {
+	unit.HP = 0
+	unit.Path = nil
+	unit.Target = 0
+	unit.Anim.Play(anim.StateDie)
+	if a, ok := unit.Anim.Anim(); ok {
+		a.CurFrame = a.NFrames
+	}
+}
+
+// Face turns the unit to face the given position.
+func (unit *Unit) Face(x, y float64) {
+	if dir, ok := facing.FromScreen(x-unit.X, y-unit.Y); ok {
+		unit.Dir = dir
+	}
+}
+
+// Frame returns the bounds of the current animation frame of the unit within
+// its sprite sheet. The boolean return value indicates if there is a frame to
+// display.
+func (unit *Unit) Frame() (image.Rectangle, bool) {
+	frame, ok := unit.Anim.FrameNum()
+	if !ok {
+		return image.Rectangle{}, false
+	}
+	sheet := unit.Type.Sheet
+	def := sheet.Defs[unit.Anim.State]
+	return sheet.FrameRect(def, sheet.Row(unit.Dir), frame), true
+}
+
+// DrawPos returns the position of the top-left corner of the frames of the
+// unit, in area pixel coordinates.
+func (unit *Unit) DrawPos() (x, y float64) {
+	sheet := unit.Type.Sheet
+	return unit.X - float64(sheet.OffsetX), unit.Y - float64(sheet.OffsetY)
+}
@@ -0,0 +1,31 @@
+// Code generated by "stringer -linecomment -type Dir"; DO NOT EDIT.
+
+package facing
+
+import "strconv"
+
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[West-0]
+	_ = x[NorthWest-1]
+	_ = x[North-2]
+	_ = x[NorthEast-3]
+	_ = x[East-4]
+	_ = x[SouthEast-5]
+	_ = x[South-6]
+	_ = x[SouthWest-7]
+}
+
+const _Dir_name = "WNWNNEESESSW"
+
+var _Dir_index = [...]uint8{0, 1, 3, 4, 6, 7, 9, 10, 12}
+
+func (i Dir) String() string {
+	idx := int(i) - 0
+	if i < 0 || idx >= len(_Dir_index)-1 {
+		return "Dir(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _Dir_name[_Dir_index[idx]:_Dir_index[idx+1]]
+}
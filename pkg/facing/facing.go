@@ -0,0 +1,118 @@
+package facing
+
+import (
+	"fmt"
+	"math"
+)
+
+//go:generate stringer -linecomment -type Dir
+
+// Dir specifies a facing direction on screen.
+type Dir uint8
+
+// Facing directions, in the row order of Flare sprite sheets.
+const (
+	// west (left)
+	West Dir = iota // W
+	// north-west (up-left)
+	NorthWest // NW
+	// north (up)
+	North // N
+	// north-east (up-right)
+	NorthEast // NE
+	// east (right)
+	East // E
+	// south-east (down-right)
+	SouthEast // SE
+	// south (down)
+	South // S
+	// south-west (down-left)
+	SouthWest // SW
+)
+
+// NDirs is the number of facing directions.
+const NDirs = 8
+
+// FromWorld returns the facing direction of the given vector in world
+// (isometric map) coordinates. The boolean return value indicates if the vector
+// has a direction; i.e. is non-zero.
+//
+// The world x-axis points south-east on screen and the world y-axis points
+// south-west on screen.
+func FromWorld(dx, dy float64) (Dir, bool) {
+	if dx == 0 && dy == 0 {
+		return 0, false
+	}
+	// The world vector (1, 0) faces south-east; each eighth of a turn clockwise
+	// (in screen space) advances the direction by one.
+	theta := math.Atan2(dy, dx)
+	sector := int(math.Round(theta / (math.Pi / 4)))
+	d := (sector + int(SouthEast)) % NDirs
+	if d < 0 {
+		d += NDirs
+	}
+	return Dir(d), true
+}
+
+// FromScreen returns the facing direction of the given vector in screen
+// coordinates (y-axis pointing down). The boolean return value indicates if the
+// vector has a direction; i.e. is non-zero.
+//
+// The vector is classified in world space, so that movement along the axes of
+// the isometric map faces the diagonal directions of the sprite sheet.
+func FromScreen(dx, dy float64) (Dir, bool) {
+	wx, wy := ScreenToWorld(dx, dy)
+	return FromWorld(wx, wy)
+}
+
+// Parse returns the facing direction of the given direction name (e.g. "NW").
+func Parse(s string) (Dir, error) {
+	for dir := Dir(0); dir < NDirs; dir++ {
+		if dir.String() == s {
+			return dir, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid facing direction %q; expected one of W, NW, N, NE, E, SE, S or SW", s)
+}
+
+// ScreenVector returns the unit vector in screen coordinates of the facing
+// direction; the inverse of FromScreen.
+func (dir Dir) ScreenVector() (dx, dy float64) {
+	theta := float64(int(dir)-int(SouthEast)) * (math.Pi / 4)
+	sx, sy := WorldToScreen(math.Cos(theta), math.Sin(theta))
+	n := math.Hypot(sx, sy)
+	return sx / n, sy / n
+}
+
+// WorldToScreen converts the given world (isometric map) vector to screen
+// coordinates, using a 2:1 isometric projection.
+func WorldToScreen(wx, wy float64) (sx, sy float64) {
+	sx = wx - wy
+	sy = (wx + wy) / 2
+	return sx, sy
+}
+
+// ScreenToWorld converts the given screen vector to world (isometric map)
+// coordinates, using a 2:1 isometric projection.
+func ScreenToWorld(sx, sy float64) (wx, wy float64) {
+	wx = sy + sx/2
+	wy = sy - sx/2
+	return wx, wy
+}
+
+// Layout specifies the facing direction of each row of a sprite sheet.
+type Layout [NDirs]Dir
+
+// FlareLayout is the sprite sheet row layout used by Flare; starting with west
+// and continuing clockwise.
+var FlareLayout = Layout{West, NorthWest, North, NorthEast, East, SouthEast, South, SouthWest}
+
+// Row returns the sprite sheet row of the given facing direction.
+func (layout *Layout) Row(dir Dir) int {
+	for row, d := range layout {
+		if d == dir {
+			return row
+		}
+	}
+	panic(fmt.Errorf("unable to locate sprite sheet row of direction %v", dir))
+}
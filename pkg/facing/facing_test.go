@@ -0,0 +1,142 @@
+package facing
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFromWorld(t *testing.T) {
+	golden := []struct {
+		dx, dy float64
+		want   Dir
+	}{
+		{dx: 1, dy: 0, want: SouthEast},
+		{dx: 1, dy: 1, want: South},
+		{dx: 0, dy: 1, want: SouthWest},
+		{dx: -1, dy: 1, want: West},
+		{dx: -1, dy: 0, want: NorthWest},
+		{dx: -1, dy: math.Copysign(0, -1), want: NorthWest},
+		{dx: -1, dy: -1, want: North},
+		{dx: 0, dy: -1, want: NorthEast},
+		{dx: 1, dy: -1, want: East},
+		// Magnitude does not affect direction.
+		{dx: 1000, dy: 0.001, want: SouthEast},
+	}
+	for _, g := range golden {
+		got, ok := FromWorld(g.dx, g.dy)
+		if !ok {
+			t.Errorf("FromWorld(%v, %v): expected direction, got none", g.dx, g.dy)
+			continue
+		}
+		if got != g.want {
+			t.Errorf("FromWorld(%v, %v): direction mismatch; expected %v, got %v", g.dx, g.dy, g.want, got)
+		}
+	}
+}
+
+func TestFromScreen(t *testing.T) {
+	golden := []struct {
+		dx, dy float64
+		want   Dir
+	}{
+		{dx: -1, dy: 0, want: West},
+		{dx: -1, dy: -1, want: NorthWest},
+		{dx: 0, dy: -1, want: North},
+		{dx: 1, dy: -1, want: NorthEast},
+		{dx: 1, dy: 0, want: East},
+		{dx: 1, dy: 1, want: SouthEast},
+		{dx: 0, dy: 1, want: South},
+		{dx: -1, dy: 1, want: SouthWest},
+	}
+	for _, g := range golden {
+		got, ok := FromScreen(g.dx, g.dy)
+		if !ok {
+			t.Errorf("FromScreen(%v, %v): expected direction, got none", g.dx, g.dy)
+			continue
+		}
+		if got != g.want {
+			t.Errorf("FromScreen(%v, %v): direction mismatch; expected %v, got %v", g.dx, g.dy, g.want, got)
+		}
+	}
+}
+
+func TestZeroVector(t *testing.T) {
+	if dir, ok := FromWorld(0, 0); ok {
+		t.Errorf("FromWorld(0, 0): expected no direction, got %v", dir)
+	}
+	if dir, ok := FromScreen(0, 0); ok {
+		t.Errorf("FromScreen(0, 0): expected no direction, got %v", dir)
+	}
+}
+
+func TestBoundaryAngles(t *testing.T) {
+	// Sector boundaries lie halfway between directions (22.5 degrees off the
+	// direction); angles just within either side of the boundary face the
+	// closest direction.
+	const eps = 0.1 * math.Pi / 180
+	for dir := Dir(0); dir < NDirs; dir++ {
+		center := float64(int(dir)-int(SouthEast)) * (math.Pi / 4)
+		for _, delta := range []float64{-math.Pi/8 + eps, math.Pi/8 - eps} {
+			theta := center + delta
+			got, ok := FromWorld(math.Cos(theta), math.Sin(theta))
+			if !ok || got != dir {
+				t.Errorf("FromWorld at %.2f degrees: direction mismatch; expected %v, got %v", theta*180/math.Pi, dir, got)
+			}
+		}
+		// Just past the clockwise boundary faces the next direction.
+		theta := center + math.Pi/8 + eps
+		want := (dir + 1) % NDirs
+		if got, _ := FromWorld(math.Cos(theta), math.Sin(theta)); got != want {
+			t.Errorf("FromWorld at %.2f degrees: direction mismatch; expected %v, got %v", theta*180/math.Pi, want, got)
+		}
+	}
+}
+
+func TestScreenVector(t *testing.T) {
+	for dir := Dir(0); dir < NDirs; dir++ {
+		dx, dy := dir.ScreenVector()
+		if n := math.Hypot(dx, dy); math.Abs(n-1) > 1e-9 {
+			t.Errorf("%v.ScreenVector(): expected unit vector, got length %v", dir, n)
+		}
+		if got, ok := FromScreen(dx, dy); !ok || got != dir {
+			t.Errorf("FromScreen(%v.ScreenVector()): direction mismatch; expected %v, got %v", dir, dir, got)
+		}
+	}
+}
+
+func TestFlareLayout(t *testing.T) {
+	golden := []struct {
+		dir  Dir
+		want int
+	}{
+		{dir: West, want: 0},
+		{dir: NorthWest, want: 1},
+		{dir: North, want: 2},
+		{dir: NorthEast, want: 3},
+		{dir: East, want: 4},
+		{dir: SouthEast, want: 5},
+		{dir: South, want: 6},
+		{dir: SouthWest, want: 7},
+	}
+	for _, g := range golden {
+		if got := FlareLayout.Row(g.dir); got != g.want {
+			t.Errorf("FlareLayout.Row(%v): row mismatch; expected %d, got %d", g.dir, g.want, got)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	for dir := Dir(0); dir < NDirs; dir++ {
+		got, err := Parse(dir.String())
+		if err != nil {
+			t.Errorf("Parse(%q): unexpected error; %v", dir.String(), err)
+			continue
+		}
+		if got != dir {
+			t.Errorf("Parse(%q): direction mismatch; expected %v, got %v", dir.String(), dir, got)
+		}
+	}
+	if _, err := Parse("X"); err == nil {
+		t.Errorf("Parse(%q): expected error, got nil", "X")
+	}
+}
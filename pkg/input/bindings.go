@@ -0,0 +1,86 @@
+package input
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// Bindings maps actions to the physical inputs bound to them.
+type Bindings map[Action][]Binding
+
+// DefaultBindings returns the default input bindings.
+func DefaultBindings() Bindings {
+	return Bindings{
+		ActionMoveUp:          {{DeviceKey, "Up"}},
+		ActionMoveDown:        {{DeviceKey, "Down"}},
+		ActionMoveLeft:        {{DeviceKey, "Left"}},
+		ActionMoveRight:       {{DeviceKey, "Right"}},
+		ActionPanUp:           {{DeviceKey, "W"}},
+		ActionPanDown:         {{DeviceKey, "S"}},
+		ActionPanLeft:         {{DeviceKey, "A"}},
+		ActionPanRight:        {{DeviceKey, "D"}},
+		ActionSelect:          {{DeviceMouse, "Left"}},
+		ActionAddSelect:       {{DeviceKey, "Shift"}},
+		ActionMoveOrder:       {{DeviceMouse, "Right"}},
+		ActionAttack:          {{DeviceKey, "Space"}, {DeviceGamepad, "0"}},
+		ActionShoot:           {{DeviceKey, "E"}, {DeviceGamepad, "1"}},
+		ActionPause:           {{DeviceKey, "P"}, {DeviceGamepad, "9"}},
+		ActionQuicksave:       {{DeviceKey, "F5"}},
+		ActionQuickload:       {{DeviceKey, "F9"}},
+		ActionRecord:          {{DeviceKey, "F8"}},
+		ActionFollow:          {{DeviceKey, "F"}},
+		ActionToggleLighting:  {{DeviceKey, "L"}},
+		ActionToggleOcclusion: {{DeviceKey, "O"}},
+		ActionToggleDebug:     {{DeviceKey, "F3"}},
+		ActionDebugLayer:      {{DeviceKey, "F4"}},
+	}
+}
+
+// Load loads input bindings from the given JSON file, mapping action names to
+// lists of bindings; e.g.
+//
+//	{
+//	   "attack": ["key:Space", "gamepad:0"],
+//	   "pause": ["key:P"]
+//	}
+//
+// Actions not present in the file keep their default bindings. The default
+// bindings are returned if the file does not exist. Unknown actions, devices,
+// keys and buttons are rejected.
+func Load(path string) (Bindings, error) {
+	bindings := DefaultBindings()
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return bindings, nil
+		}
+		return nil, errors.WithStack(err)
+	}
+	var custom Bindings
+	if err := json.Unmarshal(buf, &custom); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse input bindings %q", path)
+	}
+	for action, bs := range custom {
+		// Every action has a default binding.
+		if _, ok := bindings[action]; !ok {
+			return nil, errors.Errorf("unknown action %q in input bindings %q", action, path)
+		}
+		bindings[action] = bs
+	}
+	return bindings, nil
+}
+
+// Save stores the input bindings to the given JSON file.
+func (bindings Bindings) Save(path string) error {
+	buf, err := json.MarshalIndent(bindings, "", "\t")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := ioutil.WriteFile(path, buf, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package input
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Action is a named input action.
+type Action string
+
+// Input actions.
+const (
+	// Move player unit.
+	ActionMoveUp    Action = "move_up"
+	ActionMoveDown  Action = "move_down"
+	ActionMoveLeft  Action = "move_left"
+	ActionMoveRight Action = "move_right"
+	// Pan camera.
+	ActionPanUp    Action = "pan_up"
+	ActionPanDown  Action = "pan_down"
+	ActionPanLeft  Action = "pan_left"
+	ActionPanRight Action = "pan_right"
+	// Select units by click or drag selection.
+	ActionSelect Action = "select"
+	// Add to selection (modifier of select).
+	ActionAddSelect Action = "add_select"
+	// Order selected units to move to cursor, or to attack the unit at cursor.
+	ActionMoveOrder Action = "move_order"
+	// Attack with player unit towards cursor.
+	ActionAttack Action = "attack"
+	// Fire ranged attack of player unit towards cursor.
+	ActionShoot Action = "shoot"
+	// Pause or resume simulation.
+	ActionPause Action = "pause"
+	// Save game to quicksave slot.
+	ActionQuicksave Action = "quicksave"
+	// Load game from quicksave slot.
+	ActionQuickload Action = "quickload"
+	// Start or stop recording of play session.
+	ActionRecord Action = "record"
+	// Toggle camera following of player unit.
+	ActionFollow Action = "follow"
+	// Toggle lighting.
+	ActionToggleLighting Action = "toggle_lighting"
+	// Toggle occlusion of units behind scenery.
+	ActionToggleOcclusion Action = "toggle_occlusion"
+	// Toggle debug overlay.
+	ActionToggleDebug Action = "toggle_debug"
+	// Cycle layer drawn as background (e.g. height layer or walkability grid).
+	ActionDebugLayer Action = "debug_layer"
+)
+
+// Device specifies an input device.
+type Device string
+
+// Input devices.
+const (
+	// Keyboard; bindings are named by key (e.g. "Space", "A", "Up").
+	DeviceKey Device = "key"
+	// Mouse; bindings are named by button ("Left", "Right" or "Middle").
+	DeviceMouse Device = "mouse"
+	// Gamepad; bindings are named by button number (e.g. "0").
+	DeviceGamepad Device = "gamepad"
+)
+
+// Binding is a physical input (key, mouse button or gamepad button) bound to
+// an action. The textual representation of bindings is "DEVICE:NAME" (e.g.
+// "key:Space", "mouse:Left", "gamepad:0").
+type Binding struct {
+	// Input device.
+	Device Device
+	// Input name (e.g. key name or button number).
+	Name string
+}
+
+// ParseBinding parses the given textual representation of a binding.
+func ParseBinding(s string) (Binding, error) {
+	pos := strings.IndexByte(s, ':')
+	if pos == -1 {
+		return Binding{}, errors.Errorf("invalid binding %q; expected DEVICE:NAME", s)
+	}
+	b := Binding{Device: Device(s[:pos]), Name: s[pos+1:]}
+	switch b.Device {
+	case DeviceKey:
+		if !keyNames[b.Name] {
+			return Binding{}, errors.Errorf("invalid key %q of binding %q", b.Name, s)
+		}
+	case DeviceMouse:
+		switch b.Name {
+		case "Left", "Right", "Middle":
+		default:
+			return Binding{}, errors.Errorf("invalid mouse button %q of binding %q", b.Name, s)
+		}
+	case DeviceGamepad:
+		if _, err := strconv.Atoi(b.Name); err != nil {
+			return Binding{}, errors.Errorf("invalid gamepad button %q of binding %q", b.Name, s)
+		}
+	default:
+		return Binding{}, errors.Errorf("invalid device %q of binding %q", b.Device, s)
+	}
+	return b, nil
+}
+
+// String returns the textual representation of the binding.
+func (b Binding) String() string {
+	return fmt.Sprintf("%s:%s", b.Device, b.Name)
+}
+
+// MarshalText encodes the binding in textual representation.
+func (b Binding) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText decodes the binding from textual representation.
+func (b *Binding) UnmarshalText(text []byte) error {
+	v, err := ParseBinding(string(text))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	*b = v
+	return nil
+}
+
+// Source is a source of physical input (e.g. Ebiten, or synthetic input in
+// tests).
+type Source interface {
+	// Pressed reports whether the given physical input is pressed.
+	Pressed(b Binding) bool
+	// CursorPosition returns the position of the mouse cursor in screen
+	// coordinates.
+	CursorPosition() (x, y int)
+	// Wheel returns the mouse wheel offset since last tick.
+	Wheel() (xoff, yoff float64)
+}
+
+// State is the state of input actions, updated once per tick from a source of
+// physical input.
+type State struct {
+	// Bindings of actions to physical inputs.
+	Bindings Bindings
+	// Position of the mouse cursor in screen coordinates.
+	CursorX, CursorY int
+	// Mouse wheel offset of current tick.
+	WheelX, WheelY float64
+	// Pressed actions of current and previous tick.
+	cur, prev map[Action]bool
+}
+
+// NewState returns a new input state using the given bindings.
+func NewState(bindings Bindings) *State {
+	return &State{
+		Bindings: bindings,
+		cur:      make(map[Action]bool),
+		prev:     make(map[Action]bool),
+	}
+}
+
+// Update updates the input state from the given source of physical input.
+func (s *State) Update(src Source) {
+	s.prev, s.cur = s.cur, s.prev
+	for action := range s.cur {
+		delete(s.cur, action)
+	}
+	for action, bindings := range s.Bindings {
+		for _, b := range bindings {
+			if src.Pressed(b) {
+				s.cur[action] = true
+				break
+			}
+		}
+	}
+	s.CursorX, s.CursorY = src.CursorPosition()
+	s.WheelX, s.WheelY = src.Wheel()
+}
+
+// Pressed reports whether the given action is pressed.
+func (s *State) Pressed(action Action) bool {
+	return s.cur[action]
+}
+
+// JustPressed reports whether the given action was pressed this tick.
+func (s *State) JustPressed(action Action) bool {
+	return s.cur[action] && !s.prev[action]
+}
+
+// JustReleased reports whether the given action was released this tick.
+func (s *State) JustReleased(action Action) bool {
+	return !s.cur[action] && s.prev[action]
+}
+
+// Synthetic is a synthetic source of physical input; for injecting input in
+// tests and headless runs.
+type Synthetic struct {
+	// Pressed physical inputs.
+	Down map[Binding]bool
+	// Position of the mouse cursor in screen coordinates.
+	X, Y int
+	// Mouse wheel offset.
+	WheelX, WheelY float64
+}
+
+// NewSynthetic returns a new synthetic source of physical input, with no input
+// pressed.
+func NewSynthetic() *Synthetic {
+	return &Synthetic{
+		Down: make(map[Binding]bool),
+	}
+}
+
+// Press presses the given physical input.
+func (s *Synthetic) Press(b Binding) {
+	s.Down[b] = true
+}
+
+// Release releases the given physical input.
+func (s *Synthetic) Release(b Binding) {
+	delete(s.Down, b)
+}
+
+// Pressed reports whether the given physical input is pressed.
+func (s *Synthetic) Pressed(b Binding) bool {
+	return s.Down[b]
+}
+
+// CursorPosition returns the position of the mouse cursor in screen
+// coordinates.
+func (s *Synthetic) CursorPosition() (x, y int) {
+	return s.X, s.Y
+}
+
+// Wheel returns the mouse wheel offset since last tick.
+func (s *Synthetic) Wheel() (xoff, yoff float64) {
+	return s.WheelX, s.WheelY
+}
@@ -0,0 +1,117 @@
+package input
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSynthetic(t *testing.T) {
+	src := NewSynthetic()
+	s := NewState(DefaultBindings())
+	space := Binding{Device: DeviceKey, Name: "Space"}
+	src.Press(space)
+	src.X, src.Y = 10, 20
+	src.WheelY = -1
+	s.Update(src)
+	if !s.Pressed(ActionAttack) || !s.JustPressed(ActionAttack) {
+		t.Errorf("expected attack just pressed")
+	}
+	if s.CursorX != 10 || s.CursorY != 20 {
+		t.Errorf("cursor position mismatch; expected (10, 20), got (%d, %d)", s.CursorX, s.CursorY)
+	}
+	if s.WheelY != -1 {
+		t.Errorf("wheel offset mismatch; expected -1, got %v", s.WheelY)
+	}
+	// Held inputs remain pressed, but not just pressed.
+	s.Update(src)
+	if !s.Pressed(ActionAttack) || s.JustPressed(ActionAttack) {
+		t.Errorf("expected attack held")
+	}
+	src.Release(space)
+	s.Update(src)
+	if s.Pressed(ActionAttack) || !s.JustReleased(ActionAttack) {
+		t.Errorf("expected attack just released")
+	}
+	// Any of the bindings of an action presses the action.
+	src.Press(Binding{Device: DeviceGamepad, Name: "0"})
+	s.Update(src)
+	if !s.JustPressed(ActionAttack) {
+		t.Errorf("expected attack just pressed by gamepad")
+	}
+	if s.Pressed(ActionPause) {
+		t.Errorf("expected pause not pressed")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// Missing bindings files use the default bindings.
+	bindings, err := Load(filepath.Join(dir, "missing.json"))
+	if err != nil {
+		t.Fatalf("unable to load missing bindings; %+v", err)
+	}
+	if !reflect.DeepEqual(bindings, DefaultBindings()) {
+		t.Errorf("expected default bindings, got %v", bindings)
+	}
+	golden := []struct {
+		name    string
+		json    string
+		wantErr bool
+	}{
+		{name: "valid", json: `{"attack": ["key:A", "mouse:Middle", "gamepad:2"]}`},
+		{name: "unknown action", json: `{"dance": ["key:A"]}`, wantErr: true},
+		{name: "unknown key", json: `{"attack": ["key:Hyper"]}`, wantErr: true},
+		{name: "unknown mouse button", json: `{"attack": ["mouse:Fourth"]}`, wantErr: true},
+		{name: "unknown device", json: `{"attack": ["joystick:0"]}`, wantErr: true},
+		{name: "invalid gamepad button", json: `{"attack": ["gamepad:X"]}`, wantErr: true},
+	}
+	for _, g := range golden {
+		path := filepath.Join(dir, "bindings.json")
+		if err := ioutil.WriteFile(path, []byte(g.json), 0644); err != nil {
+			t.Fatal(err)
+		}
+		bindings, err := Load(path)
+		if g.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error, got nil", g.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unable to load bindings; %+v", g.name, err)
+			continue
+		}
+		want := []Binding{{DeviceKey, "A"}, {DeviceMouse, "Middle"}, {DeviceGamepad, "2"}}
+		if !reflect.DeepEqual(bindings[ActionAttack], want) {
+			t.Errorf("%s: attack bindings mismatch; expected %v, got %v", g.name, want, bindings[ActionAttack])
+		}
+		// Actions not present in the file keep their default bindings.
+		if !reflect.DeepEqual(bindings[ActionPause], DefaultBindings()[ActionPause]) {
+			t.Errorf("%s: pause bindings mismatch; expected %v, got %v", g.name, DefaultBindings()[ActionPause], bindings[ActionPause])
+		}
+	}
+}
+
+func TestDefaultBindings(t *testing.T) {
+	// Default bindings survive a round-trip through their textual
+	// representation.
+	for action, bs := range DefaultBindings() {
+		for _, b := range bs {
+			got, err := ParseBinding(b.String())
+			if err != nil {
+				t.Errorf("%s: unable to parse binding %q; %v", action, b, err)
+				continue
+			}
+			if got != b {
+				t.Errorf("%s: binding mismatch; expected %v, got %v", action, b, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package input
+
+// keyNames is the set of valid key names of key bindings; the names of Ebiten
+// keys (see ebiten.Key.String).
+var keyNames = map[string]bool{
+	"0": true, "1": true, "2": true, "3": true, "4": true,
+	"5": true, "6": true, "7": true, "8": true, "9": true,
+	"A": true, "B": true, "C": true, "D": true, "E": true, "F": true, "G": true,
+	"H": true, "I": true, "J": true, "K": true, "L": true, "M": true, "N": true,
+	"O": true, "P": true, "Q": true, "R": true, "S": true, "T": true, "U": true,
+	"V": true, "W": true, "X": true, "Y": true, "Z": true,
+	"F1": true, "F2": true, "F3": true, "F4": true, "F5": true, "F6": true,
+	"F7": true, "F8": true, "F9": true, "F10": true, "F11": true, "F12": true,
+	"KP0": true, "KP1": true, "KP2": true, "KP3": true, "KP4": true,
+	"KP5": true, "KP6": true, "KP7": true, "KP8": true, "KP9": true,
+	"KPAdd": true, "KPDecimal": true, "KPDivide": true, "KPEnter": true,
+	"KPEqual": true, "KPMultiply": true, "KPSubtract": true,
+	"Alt": true, "Apostrophe": true, "Backslash": true, "Backspace": true,
+	"CapsLock": true, "Comma": true, "Control": true, "Delete": true,
+	"Down": true, "End": true, "Enter": true, "Equal": true, "Escape": true,
+	"GraveAccent": true, "Home": true, "Insert": true, "Left": true,
+	"LeftBracket": true, "Menu": true, "Minus": true, "NumLock": true,
+	"PageDown": true, "PageUp": true, "Pause": true, "Period": true,
+	"PrintScreen": true, "Right": true, "RightBracket": true,
+	"ScrollLock": true, "Semicolon": true, "Shift": true, "Slash": true,
+	"Space": true, "Tab": true, "Up": true,
+}
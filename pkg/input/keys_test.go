@@ -0,0 +1,28 @@
+//go:build !headless
+// +build !headless
+
+package input
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+func TestKeyNames(t *testing.T) {
+	// The key names of key bindings are the names of Ebiten keys.
+	want := make(map[string]bool)
+	for k := ebiten.Key(0); k <= ebiten.KeyMax; k++ {
+		want[k.String()] = true
+	}
+	for name := range want {
+		if !keyNames[name] {
+			t.Errorf("missing key name %q of Ebiten key", name)
+		}
+	}
+	for name := range keyNames {
+		if !want[name] {
+			t.Errorf("invalid key name %q; no such Ebiten key", name)
+		}
+	}
+}
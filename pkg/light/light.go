@@ -0,0 +1,174 @@
+package light
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Vec3 is a 3D vector.
+type Vec3 struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+// Dot returns the dot product of u and v.
+func (u Vec3) Dot(v Vec3) float64 {
+	return u.X*v.X + u.Y*v.Y + u.Z*v.Z
+}
+
+// Len returns the length of v.
+func (v Vec3) Len() float64 {
+	return math.Sqrt(v.Dot(v))
+}
+
+// Normalize returns v scaled to unit length; or the zero vector if v is zero.
+func (v Vec3) Normalize() Vec3 {
+	l := v.Len()
+	if l == 0 {
+		return Vec3{}
+	}
+	return Vec3{X: v.X / l, Y: v.Y / l, Z: v.Z / l}
+}
+
+// Color is a linear light color, with components in the range [0, 1] (or
+// above, for over-bright lights).
+type Color struct {
+	R float64 `json:"r"`
+	G float64 `json:"g"`
+	B float64 `json:"b"`
+}
+
+// add returns the sum of c and d.
+func (c Color) add(d Color) Color {
+	return Color{R: c.R + d.R, G: c.G + d.G, B: c.B + d.B}
+}
+
+// scale returns c scaled by s.
+func (c Color) scale(s float64) Color {
+	return Color{R: c.R * s, G: c.G * s, B: c.B * s}
+}
+
+// PointLight is a point light (e.g. torch, spell).
+type PointLight struct {
+	// Position of the light in world (area pixel) coordinates.
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	// Height of the light above the ground, in pixels.
+	Z float64 `json:"z"`
+	// Radius of the light in pixels; beyond which the light has no effect.
+	Radius float64 `json:"radius"`
+	// Light color.
+	Color Color `json:"color"`
+	// Light intensity.
+	Intensity float64 `json:"intensity"`
+}
+
+// Bounds returns the region of the world affected by the light.
+func (l PointLight) Bounds() image.Rectangle {
+	return image.Rect(
+		int(math.Floor(l.X-l.Radius)),
+		int(math.Floor(l.Y-l.Radius)),
+		int(math.Ceil(l.X+l.Radius)),
+		int(math.Ceil(l.Y+l.Radius)),
+	)
+}
+
+// DirLight is a directional light (e.g. sun, moon).
+type DirLight struct {
+	// Direction towards the light; the x-axis points right, the y-axis points
+	// down and the z-axis points towards the viewer.
+	Dir Vec3 `json:"dir"`
+	// Light color.
+	Color Color `json:"color"`
+	// Light intensity.
+	Intensity float64 `json:"intensity"`
+}
+
+// Scene is the lighting of a map area.
+type Scene struct {
+	// Ambient light.
+	Ambient Color `json:"ambient"`
+	// Directional light; or nil if none.
+	Dir *DirLight `json:"dir,omitempty"`
+	// Point lights.
+	Points []PointLight `json:"points,omitempty"`
+}
+
+// Shade returns the light reaching the ground at the given world position,
+// with the given surface normal.
+func (s *Scene) Shade(x, y float64, n Vec3) Color {
+	c := s.Ambient
+	if s.Dir != nil {
+		l := s.Dir.Dir.Normalize()
+		if d := n.Dot(l); d > 0 {
+			c = c.add(s.Dir.Color.scale(d * s.Dir.Intensity))
+		}
+	}
+	for _, p := range s.Points {
+		v := Vec3{X: p.X - x, Y: p.Y - y, Z: p.Z}
+		dist := v.Len()
+		if dist >= p.Radius || dist == 0 {
+			continue
+		}
+		d := n.Dot(v) / dist
+		if d <= 0 {
+			continue
+		}
+		// Quadratic falloff towards the radius of the light.
+		att := 1 - dist/p.Radius
+		att *= att
+		c = c.add(p.Color.scale(d * att * p.Intensity))
+	}
+	return c
+}
+
+// DecodeNormal decodes the surface normal of the given normal layer pixel. The
+// red, green and blue channels map the x-axis (right), y-axis (up) and z-axis
+// (towards the viewer) from [0, 255] to [-1, 1]. The returned normal uses
+// world orientation; i.e. with the y-axis pointing down.
+func DecodeNormal(c color.Color) Vec3 {
+	nc := color.NRGBAModel.Convert(c).(color.NRGBA)
+	n := Vec3{
+		X: float64(nc.R)/127.5 - 1,
+		Y: -(float64(nc.G)/127.5 - 1),
+		Z: float64(nc.B)/127.5 - 1,
+	}
+	return n.Normalize()
+}
+
+// Apply lights the region of the background layer covered by dst, using the
+// normals of the given normal layer, and stores the result in dst. The normal
+// layer may have a lower resolution than the background layer (e.g. half
+// resolution, as produced by dump_layers); its pixels are stretched to cover
+// the background layer.
+func Apply(dst *image.RGBA, bg, normal image.Image, s *Scene) {
+	bb := bg.Bounds()
+	nb := normal.Bounds()
+	sx := float64(nb.Dx()) / float64(bb.Dx())
+	sy := float64(nb.Dy()) / float64(bb.Dy())
+	r := dst.Bounds().Intersect(bb)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		ny := nb.Min.Y + int(float64(y-bb.Min.Y)*sy)
+		for x := r.Min.X; x < r.Max.X; x++ {
+			nx := nb.Min.X + int(float64(x-bb.Min.X)*sx)
+			n := DecodeNormal(normal.At(nx, ny))
+			c := s.Shade(float64(x), float64(y), n)
+			dst.SetRGBA(x, y, lit(bg.At(x, y), c))
+		}
+	}
+}
+
+// lit returns the color of the given background pixel lit by light c.
+func lit(bg color.Color, c Color) color.RGBA {
+	// Premultiplied alpha colors remain premultiplied when scaling the color
+	// channels, provided the result is clamped to alpha.
+	r, g, b, a := bg.RGBA()
+	return color.RGBA{
+		R: uint8(math.Min(float64(r)*c.R, float64(a)) / 257),
+		G: uint8(math.Min(float64(g)*c.G, float64(a)) / 257),
+		B: uint8(math.Min(float64(b)*c.B, float64(a)) / 257),
+		A: uint8(a / 257),
+	}
+}
@@ -0,0 +1,116 @@
+package light
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// Normal layer pixels of surfaces facing the viewer, right and left.
+var (
+	normalFlat  = color.RGBA{R: 128, G: 128, B: 255, A: 255}
+	normalRight = color.RGBA{R: 255, G: 128, B: 128, A: 255}
+	normalLeft  = color.RGBA{R: 0, G: 128, B: 128, A: 255}
+)
+
+func TestDecodeNormal(t *testing.T) {
+	golden := []struct {
+		c    color.Color
+		want Vec3
+	}{
+		{c: normalFlat, want: Vec3{Z: 1}},
+		{c: normalRight, want: Vec3{X: 1}},
+		{c: normalLeft, want: Vec3{X: -1}},
+		// The y-axis of normal layers points up, and of the world down.
+		{c: color.RGBA{R: 128, G: 255, B: 128, A: 255}, want: Vec3{Y: -1}},
+	}
+	for _, g := range golden {
+		got := DecodeNormal(g.c)
+		if !near(got.X, g.want.X) || !near(got.Y, g.want.Y) || !near(got.Z, g.want.Z) {
+			t.Errorf("DecodeNormal(%v): normal mismatch; expected %v, got %v", g.c, g.want, got)
+		}
+	}
+}
+
+func TestShade(t *testing.T) {
+	ambient := Color{R: 0.25, G: 0.25, B: 0.25}
+	torch := PointLight{X: 100, Y: 100, Z: 50, Radius: 200, Color: Color{R: 1, G: 0.5, B: 0}, Intensity: 2}
+	sun := &DirLight{Dir: Vec3{X: 1}, Color: Color{R: 1, G: 1, B: 1}, Intensity: 0.5}
+	flat := Vec3{Z: 1}
+	golden := []struct {
+		name string
+		s    Scene
+		x, y float64
+		n    Vec3
+		want Color
+	}{
+		{name: "ambient", s: Scene{Ambient: ambient}, x: 10, y: 10, n: flat, want: ambient},
+		// Directly beneath the torch; at distance 50 of radius 200, the falloff is
+		// (1 - 50/200)^2.
+		{name: "beneath point light", s: Scene{Ambient: ambient, Points: []PointLight{torch}}, x: 100, y: 100, n: flat, want: Color{R: 0.25 + 2*0.5625, G: 0.25 + 0.5625, B: 0.25}},
+		{name: "outside point light", s: Scene{Ambient: ambient, Points: []PointLight{torch}}, x: 400, y: 100, n: flat, want: ambient},
+		{name: "facing directional light", s: Scene{Ambient: ambient, Dir: sun}, n: Vec3{X: 1}, want: Color{R: 0.75, G: 0.75, B: 0.75}},
+		{name: "facing away from directional light", s: Scene{Ambient: ambient, Dir: sun}, n: Vec3{X: -1}, want: ambient},
+	}
+	for _, g := range golden {
+		got := g.s.Shade(g.x, g.y, g.n)
+		if !near(got.R, g.want.R) || !near(got.G, g.want.G) || !near(got.B, g.want.B) {
+			t.Errorf("%s: light mismatch; expected %v, got %v", g.name, g.want, got)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	// A 4x4 background lit by a half resolution normal layer; the left half
+	// faces the light and the right half faces away.
+	bg := image.NewUniform(color.RGBA{R: 200, G: 100, B: 50, A: 255})
+	bb := image.Rect(0, 0, 4, 4)
+	normal := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	for y := 0; y < 2; y++ {
+		normal.SetRGBA(0, y, normalRight)
+		normal.SetRGBA(1, y, normalLeft)
+	}
+	s := &Scene{
+		Ambient: Color{R: 0.5, G: 0.5, B: 0.5},
+		Dir:     &DirLight{Dir: Vec3{X: 1}, Color: Color{R: 1, G: 1, B: 1}, Intensity: 1},
+	}
+	dst := image.NewRGBA(bb)
+	Apply(dst, &boundedImage{Image: bg, bounds: bb}, normal, s)
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			// Over-bright light (1.5) clamps to white.
+			want := color.RGBA{R: 255, G: 150, B: 75, A: 255}
+			if x >= 2 {
+				want = color.RGBA{R: 100, G: 50, B: 25, A: 255}
+			}
+			if got := dst.RGBAAt(x, y); !nearRGBA(got, want) {
+				t.Errorf("pixel mismatch at (%d, %d); expected %v, got %v", x, y, want, got)
+			}
+		}
+	}
+}
+
+// boundedImage is an image of the given bounds.
+type boundedImage struct {
+	image.Image
+	bounds image.Rectangle
+}
+
+// Bounds returns the bounds of the image.
+func (img *boundedImage) Bounds() image.Rectangle {
+	return img.bounds
+}
+
+// near reports whether a and b are approximately equal.
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 0.01
+}
+
+// nearRGBA reports whether the colors a and b are equal, up to rounding.
+func nearRGBA(a, b color.RGBA) bool {
+	d := func(x, y uint8) bool {
+		return math.Abs(float64(x)-float64(y)) <= 1
+	}
+	return d(a.R, b.R) && d(a.G, b.G) && d(a.B, b.B) && d(a.A, b.A)
+}
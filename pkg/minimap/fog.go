@@ -0,0 +1,129 @@
+package minimap
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// FogCellSize is the width and height in world pixels of fog of war cells.
+const FogCellSize = 32
+
+// FogColor is the color of unexplored fog of war cells.
+var FogColor = color.NRGBA{A: 0xFF}
+
+// Fog is the fog of war of a map area; the cells of the map area explored by
+// the party of the player.
+type Fog struct {
+	// Bounds of the map area, in world coordinates.
+	Bounds image.Rectangle
+	// Width and height of cells in world pixels.
+	CellSize int
+	// Number of cell columns and rows.
+	cols, rows int
+	// Specifies whether a cell is explored, in row-major order.
+	explored []bool
+	// Fog image, with one pixel per cell; FogColor for unexplored cells and
+	// transparent for explored cells.
+	img *image.NRGBA
+	// Revision of fog image.
+	rev int
+}
+
+// NewFog returns a new fog of war covering the given map area bounds, with
+// cells of the given size; no cell is explored.
+func NewFog(bounds image.Rectangle, cellSize int) *Fog {
+	cols := (bounds.Dx() + cellSize - 1) / cellSize
+	rows := (bounds.Dy() + cellSize - 1) / cellSize
+	f := &Fog{
+		Bounds:   bounds,
+		CellSize: cellSize,
+		cols:     cols,
+		rows:     rows,
+		explored: make([]bool, cols*rows),
+		img:      image.NewNRGBA(image.Rect(0, 0, cols, rows)),
+	}
+	for i := range f.explored {
+		f.img.SetNRGBA(i%cols, i/cols, FogColor)
+	}
+	return f
+}
+
+// Reveal explores the cells whose center is within the given radius of (x, y)
+// in world coordinates.
+func (f *Fog) Reveal(x, y, radius float64) {
+	cs := float64(f.CellSize)
+	minCol := int(math.Floor((x - radius - float64(f.Bounds.Min.X)) / cs))
+	maxCol := int(math.Floor((x + radius - float64(f.Bounds.Min.X)) / cs))
+	minRow := int(math.Floor((y - radius - float64(f.Bounds.Min.Y)) / cs))
+	maxRow := int(math.Floor((y + radius - float64(f.Bounds.Min.Y)) / cs))
+	changed := false
+	for row := max(minRow, 0); row <= maxRow && row < f.rows; row++ {
+		for col := max(minCol, 0); col <= maxCol && col < f.cols; col++ {
+			i := row*f.cols + col
+			if f.explored[i] {
+				continue
+			}
+			cx := float64(f.Bounds.Min.X) + (float64(col)+0.5)*cs
+			cy := float64(f.Bounds.Min.Y) + (float64(row)+0.5)*cs
+			if math.Hypot(cx-x, cy-y) > radius {
+				continue
+			}
+			f.explored[i] = true
+			f.img.SetNRGBA(col, row, color.NRGBA{})
+			changed = true
+		}
+	}
+	if changed {
+		f.rev++
+	}
+}
+
+// Explored reports whether the cell containing (x, y) in world coordinates is
+// explored.
+func (f *Fog) Explored(x, y float64) bool {
+	col := int(math.Floor((x - float64(f.Bounds.Min.X)) / float64(f.CellSize)))
+	row := int(math.Floor((y - float64(f.Bounds.Min.Y)) / float64(f.CellSize)))
+	if col < 0 || col >= f.cols || row < 0 || row >= f.rows {
+		return false
+	}
+	return f.explored[row*f.cols+col]
+}
+
+// Image returns the fog image, with one pixel per cell, and its revision; the
+// revision changes when cells are explored.
+func (f *Fog) Image() (image.Image, int) {
+	return f.img, f.rev
+}
+
+// Bitmap returns the explored cells of the fog of war as a bitmap, with one bit
+// per cell in row-major order.
+func (f *Fog) Bitmap() []byte {
+	buf := make([]byte, (len(f.explored)+7)/8)
+	for i, explored := range f.explored {
+		if explored {
+			buf[i/8] |= 1 << uint(i%8)
+		}
+	}
+	return buf
+}
+
+// SetBitmap sets the explored cells of the fog of war from the given bitmap
+// (see Fog.Bitmap).
+func (f *Fog) SetBitmap(buf []byte) error {
+	if want := (len(f.explored) + 7) / 8; len(buf) != want {
+		return errors.Errorf("invalid fog of war bitmap size; expected %d bytes, got %d", want, len(buf))
+	}
+	for i := range f.explored {
+		f.explored[i] = buf[i/8]&(1<<uint(i%8)) != 0
+		c := FogColor
+		if f.explored[i] {
+			c = color.NRGBA{}
+		}
+		f.img.SetNRGBA(i%f.cols, i/f.cols, c)
+	}
+	f.rev++
+	return nil
+}
@@ -0,0 +1,92 @@
+package minimap_test
+
+import (
+	"image"
+	"reflect"
+	"testing"
+
+	"github.com/mewspring/ren/pkg/minimap"
+)
+
+func TestReveal(t *testing.T) {
+	// A map area of 4x3 cells of 10x10 pixels, the last column and row of which
+	// are cut short by the area edges.
+	bounds := image.Rect(100, 200, 135, 225)
+	golden := []struct {
+		name   string
+		x, y   float64
+		radius float64
+		// Explored cells, in row-major order.
+		want []string
+	}{
+		{name: "cell center", x: 115, y: 215, radius: 1, want: []string{"....", ".#..", "...."}},
+		{name: "neighbours", x: 115, y: 215, radius: 10, want: []string{".#..", "###.", ".#.."}},
+		{name: "top-left corner", x: 100, y: 200, radius: 8, want: []string{"#...", "....", "...."}},
+		{name: "bottom-right corner", x: 135, y: 225, radius: 5, want: []string{"....", "....", "...#"}},
+		{name: "outside of area", x: 0, y: 0, radius: 100, want: []string{"....", "....", "...."}},
+		{name: "whole area", x: 115, y: 215, radius: 100, want: []string{"####", "####", "####"}},
+	}
+	for _, g := range golden {
+		f := minimap.NewFog(bounds, 10)
+		f.Reveal(g.x, g.y, g.radius)
+		got := exploredCells(f, 4, 3)
+		if !reflect.DeepEqual(got, g.want) {
+			t.Errorf("%s: explored cells mismatch; expected %q, got %q", g.name, g.want, got)
+		}
+	}
+}
+
+func TestBitmap(t *testing.T) {
+	bounds := image.Rect(100, 200, 135, 225)
+	f := minimap.NewFog(bounds, 10)
+	f.Reveal(115, 215, 10)
+	_, rev := f.Image()
+	buf := f.Bitmap()
+	// 12 cells in 2 bytes.
+	if want := []byte{0x72, 0x02}; !reflect.DeepEqual(buf, want) {
+		t.Errorf("bitmap mismatch; expected %x, got %x", want, buf)
+	}
+	g := minimap.NewFog(bounds, 10)
+	if err := g.SetBitmap(buf); err != nil {
+		t.Fatalf("unable to set bitmap; %+v", err)
+	}
+	if got, want := exploredCells(g, 4, 3), exploredCells(f, 4, 3); !reflect.DeepEqual(got, want) {
+		t.Errorf("explored cells mismatch; expected %q, got %q", want, got)
+	}
+	want, _ := f.Image()
+	if got, _ := g.Image(); !reflect.DeepEqual(got, want) {
+		t.Errorf("fog image mismatch")
+	}
+	// Bitmaps of other fog sizes are rejected, keeping the explored cells.
+	for _, n := range []int{0, 1, 3} {
+		if err := f.SetBitmap(make([]byte, n)); err == nil {
+			t.Errorf("expected error setting bitmap of %d bytes", n)
+		}
+	}
+	if got := f.Bitmap(); !reflect.DeepEqual(got, buf) {
+		t.Errorf("bitmap mismatch after rejected bitmap; expected %x, got %x", buf, got)
+	}
+	if _, got := f.Image(); got != rev {
+		t.Errorf("fog image revision mismatch after rejected bitmap; expected %d, got %d", rev, got)
+	}
+}
+
+// exploredCells returns the explored cells of the given fog of war of cols x
+// rows cells, with '#' marking explored cells.
+func exploredCells(f *minimap.Fog, cols, rows int) []string {
+	var cells []string
+	for row := 0; row < rows; row++ {
+		var s []byte
+		for col := 0; col < cols; col++ {
+			x := float64(f.Bounds.Min.X + col*f.CellSize)
+			y := float64(f.Bounds.Min.Y + row*f.CellSize)
+			c := byte('.')
+			if f.Explored(x, y) {
+				c = '#'
+			}
+			s = append(s, c)
+		}
+		cells = append(cells, string(s))
+	}
+	return cells
+}
@@ -0,0 +1,119 @@
+// Package minimap implements minimaps of map areas; thumbnails of the
+// background layer covered by fog of war.
+package minimap
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/mewspring/ren/pkg/assets"
+)
+
+// ThumbSize specifies the maximum width and height in pixels of generated
+// thumbnails.
+const ThumbSize = 256
+
+// Minimap is a minimap of a map area.
+type Minimap struct {
+	// Thumbnail of the background layer of the map area.
+	Thumb image.Image
+	// Bounds of the map area, in world coordinates.
+	Bounds image.Rectangle
+	// Fog of war of the map area.
+	Fog *Fog
+}
+
+// New returns a new minimap of the given map area. The thumb layer of the area
+// is used as thumbnail if present; otherwise a thumbnail is generated from the
+// background layer.
+func New(area *assets.Area) *Minimap {
+	bounds := area.BackgroundLayer.Bounds()
+	thumb := area.ThumbLayer
+	if thumb == nil {
+		thumb = Thumbnail(area.BackgroundLayer, ThumbSize)
+	}
+	return &Minimap{
+		Thumb:  thumb,
+		Bounds: bounds,
+		Fog:    NewFog(bounds, FogCellSize),
+	}
+}
+
+// Size returns the size in screen pixels of the minimap scaled to fit within
+// maxSize x maxSize pixels, preserving the aspect ratio of the map area.
+func (m *Minimap) Size(maxSize int) (width, height int) {
+	w, h := m.Bounds.Dx(), m.Bounds.Dy()
+	if w >= h {
+		return maxSize, max(1, maxSize*h/w)
+	}
+	return max(1, maxSize*w/h), maxSize
+}
+
+// ToMap converts the given world coordinates to screen coordinates of the
+// minimap drawn at the given screen rectangle.
+func (m *Minimap) ToMap(r image.Rectangle, x, y float64) (sx, sy float64) {
+	sx = float64(r.Min.X) + (x-float64(m.Bounds.Min.X))*float64(r.Dx())/float64(m.Bounds.Dx())
+	sy = float64(r.Min.Y) + (y-float64(m.Bounds.Min.Y))*float64(r.Dy())/float64(m.Bounds.Dy())
+	return sx, sy
+}
+
+// ToWorld converts the given screen coordinates of the minimap drawn at the
+// given screen rectangle to world coordinates.
+func (m *Minimap) ToWorld(r image.Rectangle, sx, sy float64) (x, y float64) {
+	x = float64(m.Bounds.Min.X) + (sx-float64(r.Min.X))*float64(m.Bounds.Dx())/float64(r.Dx())
+	y = float64(m.Bounds.Min.Y) + (sy-float64(r.Min.Y))*float64(m.Bounds.Dy())/float64(r.Dy())
+	return x, y
+}
+
+// Thumbnail returns a thumbnail of the given image, scaled down to fit within
+// size x size pixels while preserving its aspect ratio. Each thumbnail pixel is
+// the average of the image pixels it covers.
+func Thumbnail(img image.Image, size int) *image.RGBA {
+	b := img.Bounds()
+	scale := math.Max(float64(b.Dx()), float64(b.Dy())) / float64(size)
+	if scale < 1 {
+		scale = 1
+	}
+	w := max(1, int(float64(b.Dx())/scale))
+	h := max(1, int(float64(b.Dy())/scale))
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for ty := 0; ty < h; ty++ {
+		y0 := b.Min.Y + ty*b.Dy()/h
+		y1 := b.Min.Y + (ty+1)*b.Dy()/h
+		for tx := 0; tx < w; tx++ {
+			x0 := b.Min.X + tx*b.Dx()/w
+			x1 := b.Min.X + (tx+1)*b.Dx()/w
+			var r, g, bl, a, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					cr, cg, cb, ca := img.At(x, y).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					bl += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+			if n == 0 {
+				continue
+			}
+			c := color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(bl / n),
+				A: uint16(a / n),
+			}
+			dst.Set(tx, ty, c)
+		}
+	}
+	return dst
+}
+
+// max returns the maximum of x and y.
+func max(x, y int) int {
+	if x > y {
+		return x
+	}
+	return y
+}
@@ -0,0 +1,105 @@
+package minimap_test
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/mewspring/ren/pkg/minimap"
+)
+
+func TestThumbnail(t *testing.T) {
+	golden := []struct {
+		name string
+		// Image size.
+		width, height int
+		// Maximum thumbnail size.
+		size int
+		// Expected thumbnail size.
+		wantWidth, wantHeight int
+	}{
+		{name: "landscape", width: 1000, height: 500, size: 100, wantWidth: 100, wantHeight: 50},
+		{name: "portrait", width: 300, height: 900, size: 90, wantWidth: 30, wantHeight: 90},
+		{name: "square", width: 512, height: 512, size: 256, wantWidth: 256, wantHeight: 256},
+		{name: "thin", width: 1000, height: 2, size: 100, wantWidth: 100, wantHeight: 1},
+		// Images smaller than the thumbnail are not scaled up.
+		{name: "small", width: 40, height: 30, size: 100, wantWidth: 40, wantHeight: 30},
+	}
+	for _, g := range golden {
+		img := image.NewRGBA(image.Rect(0, 0, g.width, g.height))
+		got := minimap.Thumbnail(img, g.size).Bounds()
+		if got.Dx() != g.wantWidth || got.Dy() != g.wantHeight {
+			t.Errorf("%s: thumbnail size mismatch; expected %dx%d, got %dx%d", g.name, g.wantWidth, g.wantHeight, got.Dx(), got.Dy())
+		}
+	}
+}
+
+func TestThumbnailAverage(t *testing.T) {
+	// Each thumbnail pixel is the average of the 2x2 box of image pixels it
+	// covers; of a checkerboard of black and white pixels, with a red box in the
+	// top-left corner.
+	img := image.NewRGBA(image.Rect(10, 20, 18, 24))
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			c := color.RGBA{A: 0xFF}
+			if (x+y)%2 == 0 {
+				c = color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	for y := 20; y < 22; y++ {
+		for x := 10; x < 12; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: 0xFF, A: 0xFF})
+		}
+	}
+	thumb := minimap.Thumbnail(img, 4)
+	if got, want := thumb.Bounds(), image.Rect(0, 0, 4, 2); got != want {
+		t.Fatalf("thumbnail bounds mismatch; expected %v, got %v", want, got)
+	}
+	golden := []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{x: 0, y: 0, want: color.RGBA{R: 0xFF, A: 0xFF}},
+		{x: 1, y: 0, want: color.RGBA{R: 0x7F, G: 0x7F, B: 0x7F, A: 0xFF}},
+		{x: 3, y: 1, want: color.RGBA{R: 0x7F, G: 0x7F, B: 0x7F, A: 0xFF}},
+	}
+	for _, g := range golden {
+		if got := thumb.RGBAAt(g.x, g.y); got != g.want {
+			t.Errorf("thumbnail pixel mismatch at (%d, %d); expected %v, got %v", g.x, g.y, g.want, got)
+		}
+	}
+}
+
+func TestToWorld(t *testing.T) {
+	m := &minimap.Minimap{Bounds: image.Rect(-100, 200, 1900, 1200)}
+	w, h := m.Size(200)
+	if w != 200 || h != 100 {
+		t.Errorf("minimap size mismatch; expected 200x100, got %dx%d", w, h)
+	}
+	r := image.Rect(600, 10, 600+w, 10+h)
+	golden := []struct {
+		name string
+		x, y float64
+		// Expected screen coordinates.
+		sx, sy float64
+	}{
+		{name: "top-left corner", x: -100, y: 200, sx: 600, sy: 10},
+		{name: "bottom-right corner", x: 1900, y: 1200, sx: 800, sy: 110},
+		{name: "center", x: 900, y: 700, sx: 700, sy: 60},
+		{name: "interior", x: 123.5, y: 456.25, sx: 622.35, sy: 35.625},
+	}
+	for _, g := range golden {
+		sx, sy := m.ToMap(r, g.x, g.y)
+		if math.Abs(sx-g.sx) > 1e-9 || math.Abs(sy-g.sy) > 1e-9 {
+			t.Errorf("%s: screen position mismatch; expected (%v, %v), got (%v, %v)", g.name, g.sx, g.sy, sx, sy)
+		}
+		// ToWorld is the inverse of ToMap.
+		x, y := m.ToWorld(r, sx, sy)
+		if math.Abs(x-g.x) > 1e-9 || math.Abs(y-g.y) > 1e-9 {
+			t.Errorf("%s: world position mismatch; expected (%v, %v), got (%v, %v)", g.name, g.x, g.y, x, y)
+		}
+	}
+}
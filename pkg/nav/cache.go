@@ -0,0 +1,173 @@
+package nav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+
+	"github.com/mewkiz/pkg/imgutil"
+	"github.com/mewspring/ren/pkg/assets"
+	"github.com/mewspring/ren/pkg/depth"
+	"github.com/pkg/errors"
+)
+
+// CachePath returns the path of the on-disk cache of the walkability grid of
+// the given map area (AREA.nav in the assets directory).
+func CachePath(area *assets.Area) string {
+	return assets.FullPath(fmt.Sprintf("%s.nav", area.Name))
+}
+
+// Load loads the walkability grid of the given map area.
+//
+// The grid is read from its on-disk cache (see CachePath) if present and up to
+// date. Otherwise, it is derived from the height layer and the optional
+// authored walkability mask (AREA_walkmask.png), and stored in the cache. The
+// boolean return value indicates if the cache was (re)created.
+func Load(area *assets.Area) (*Grid, bool, error) {
+	bounds := area.BackgroundLayer.Bounds()
+	cachePath := CachePath(area)
+	maskPath := assets.FullPath(fmt.Sprintf("%s_walkmask.png", area.Name))
+	srcPaths := []string{area.LayerPath(assets.LayerKindHeight), maskPath}
+	if upToDate(cachePath, srcPaths) {
+		g, ok, err := readCache(cachePath, bounds, DefaultCellSize, DefaultMaxSlope)
+		if err != nil {
+			return nil, false, errors.WithStack(err)
+		}
+		if ok {
+			return g, false, nil
+		}
+		// fallthrough to rebuild cache of mismatching walkability parameters.
+	}
+	g := FromHeight(depth.New(area.HeightLayer, bounds), DefaultCellSize, DefaultMaxSlope)
+	if exists(maskPath) {
+		mask, err := imgutil.ReadFile(maskPath)
+		if err != nil {
+			return nil, false, errors.WithStack(err)
+		}
+		g.ApplyMask(mask)
+	}
+	if err := writeCache(cachePath, g, DefaultMaxSlope); err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	return g, true, nil
+}
+
+// cacheMagic identifies walkability grid cache files, and the version of the
+// cache file format.
+var cacheMagic = [4]byte{'N', 'A', 'V', '1'}
+
+// cacheHeader is the header of walkability grid cache files, recording the
+// walkability parameters used to derive the grid. The header is followed by
+// the walkability of cells in row-major order, one byte per cell.
+type cacheHeader struct {
+	// File format identifier (cacheMagic).
+	Magic [4]byte
+	// Width and height of grid cells in pixels.
+	CellSize uint32
+	// Maximum walkable slope.
+	MaxSlope float64
+	// Number of columns and rows of the grid.
+	NCols, NRows uint32
+}
+
+// readCache reads the walkability grid covering the given bounds from the given
+// cache file. The boolean return value indicates if the grid of the cache was
+// derived using the given walkability parameters.
+func readCache(cachePath string, bounds image.Rectangle, cellSize int, maxSlope float64) (*Grid, bool, error) {
+	buf, err := ioutil.ReadFile(cachePath)
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	r := bytes.NewReader(buf)
+	var hdr cacheHeader
+	if err := binary.Read(r, binary.LittleEndian, &hdr); err != nil {
+		// Truncated cache file.
+		return nil, false, nil
+	}
+	g := NewGrid(bounds, cellSize)
+	want := cacheHeader{
+		Magic:    cacheMagic,
+		CellSize: uint32(cellSize),
+		MaxSlope: maxSlope,
+		NCols:    uint32(g.NCols),
+		NRows:    uint32(g.NRows),
+	}
+	if hdr != want || r.Len() != len(g.Walkable) {
+		return nil, false, nil
+	}
+	cells := buf[len(buf)-r.Len():]
+	for i, b := range cells {
+		g.Walkable[i] = b != 0
+	}
+	return g, true, nil
+}
+
+// writeCache writes the given walkability grid, derived using the maximum
+// walkable slope maxSlope, to the given cache file.
+func writeCache(cachePath string, g *Grid, maxSlope float64) error {
+	hdr := cacheHeader{
+		Magic:    cacheMagic,
+		CellSize: uint32(g.CellSize),
+		MaxSlope: maxSlope,
+		NCols:    uint32(g.NCols),
+		NRows:    uint32(g.NRows),
+	}
+	buf := &bytes.Buffer{}
+	if err := binary.Write(buf, binary.LittleEndian, &hdr); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, walkable := range g.Walkable {
+		var b byte
+		if walkable {
+			b = 1
+		}
+		buf.WriteByte(b)
+	}
+	if err := ioutil.WriteFile(cachePath, buf.Bytes(), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// Image returns an image of the walkability grid, with one pixel per cell;
+// white for walkable and black for blocked cells.
+func (g *Grid) Image() *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, g.NCols, g.NRows))
+	for row := 0; row < g.NRows; row++ {
+		for col := 0; col < g.NCols; col++ {
+			if g.Walkable[g.index(col, row)] {
+				img.SetGray(col, row, color.Gray{Y: 0xFF})
+			}
+		}
+	}
+	return img
+}
+
+// upToDate reports whether the cache file exists and is more recent than the
+// existing source files.
+func upToDate(cachePath string, srcPaths []string) bool {
+	fi, err := os.Stat(cachePath)
+	if err != nil {
+		return false
+	}
+	for _, srcPath := range srcPaths {
+		si, err := os.Stat(srcPath)
+		if err != nil {
+			continue
+		}
+		if si.ModTime().After(fi.ModTime()) {
+			return false
+		}
+	}
+	return true
+}
+
+// exists reports whether the given file exists.
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,59 @@
+package nav
+
+import (
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cachePath := filepath.Join(dir, "test.nav")
+	bounds := image.Rect(0, 0, 100, 60)
+	g := NewGrid(bounds, 16)
+	g.Walkable[3] = false
+	if err := writeCache(cachePath, g, 0.5); err != nil {
+		t.Fatalf("unable to write cache; %+v", err)
+	}
+	got, ok, err := readCache(cachePath, bounds, 16, 0.5)
+	if err != nil {
+		t.Fatalf("unable to read cache; %+v", err)
+	}
+	if !ok {
+		t.Fatalf("expected cache of matching walkability parameters")
+	}
+	for i := range g.Walkable {
+		if got.Walkable[i] != g.Walkable[i] {
+			t.Errorf("walkability mismatch of cell %d; expected %v, got %v", i, g.Walkable[i], got.Walkable[i])
+		}
+	}
+	// Caches derived using other walkability parameters are stale.
+	golden := []struct {
+		name     string
+		bounds   image.Rectangle
+		cellSize int
+		maxSlope float64
+	}{
+		{name: "cell size", bounds: bounds, cellSize: 8, maxSlope: 0.5},
+		{name: "max slope", bounds: bounds, cellSize: 16, maxSlope: 0.75},
+		{name: "bounds", bounds: image.Rect(0, 0, 200, 60), cellSize: 16, maxSlope: 0.5},
+	}
+	for _, g := range golden {
+		if _, ok, err := readCache(cachePath, g.bounds, g.cellSize, g.maxSlope); err != nil || ok {
+			t.Errorf("%s: expected stale cache, got ok=%v, err=%v", g.name, ok, err)
+		}
+	}
+	// Truncated caches are stale.
+	if err := os.Truncate(cachePath, 10); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := readCache(cachePath, bounds, 16, 0.5); err != nil || ok {
+		t.Errorf("truncated: expected stale cache, got ok=%v, err=%v", ok, err)
+	}
+}
@@ -0,0 +1,221 @@
+package nav
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/mewspring/ren/pkg/depth"
+)
+
+// Default walkability parameters.
+const (
+	// Default width and height of grid cells in pixels.
+	DefaultCellSize = 16
+	// Default maximum walkable slope; height difference in pixels per pixel.
+	DefaultMaxSlope = 0.5
+)
+
+// Grid is a walkability grid of a map area.
+type Grid struct {
+	// Bounds of the map area in world (area pixel) coordinates.
+	Bounds image.Rectangle
+	// Width and height of grid cells in pixels.
+	CellSize int
+	// Number of columns and rows of the grid.
+	NCols, NRows int
+	// Walkability of cells, in row-major order.
+	Walkable []bool
+}
+
+// NewGrid returns a new grid covering the given bounds, with all cells
+// walkable.
+func NewGrid(bounds image.Rectangle, cellSize int) *Grid {
+	ncols := (bounds.Dx() + cellSize - 1) / cellSize
+	nrows := (bounds.Dy() + cellSize - 1) / cellSize
+	g := &Grid{
+		Bounds:   bounds,
+		CellSize: cellSize,
+		NCols:    ncols,
+		NRows:    nrows,
+		Walkable: make([]bool, ncols*nrows),
+	}
+	for i := range g.Walkable {
+		g.Walkable[i] = true
+	}
+	return g
+}
+
+// FromHeight returns a walkability grid derived from the given depth map of a
+// map area. Cells are walkable if the slope of the height layer, both within
+// the cell and towards adjacent cells, is at most maxSlope.
+func FromHeight(m *depth.Map, cellSize int, maxSlope float64) *Grid {
+	g := NewGrid(m.Bounds, cellSize)
+	// Sample distance in pixels.
+	step := cellSize / 4
+	if step < 1 {
+		step = 1
+	}
+	avg := make([]float64, len(g.Walkable))
+	for row := 0; row < g.NRows; row++ {
+		for col := 0; col < g.NCols; col++ {
+			r := g.CellRect(col, row)
+			min, max, sum, n := math.Inf(1), math.Inf(-1), 0.0, 0
+			for y := r.Min.Y; y < r.Max.Y; y += step {
+				for x := r.Min.X; x < r.Max.X; x += step {
+					h := m.HeightAt(x, y)
+					min = math.Min(min, h)
+					max = math.Max(max, h)
+					sum += h
+					n++
+				}
+			}
+			i := g.index(col, row)
+			avg[i] = sum / float64(n)
+			if (max-min)/float64(cellSize) > maxSlope {
+				g.Walkable[i] = false
+			}
+		}
+	}
+	// Block steep slopes between adjacent cells.
+	for row := 0; row < g.NRows; row++ {
+		for col := 0; col < g.NCols; col++ {
+			i := g.index(col, row)
+			for _, d := range []image.Point{{X: 1}, {Y: 1}} {
+				c, r := col+d.X, row+d.Y
+				if c >= g.NCols || r >= g.NRows {
+					continue
+				}
+				j := g.index(c, r)
+				if math.Abs(avg[i]-avg[j])/float64(cellSize) > maxSlope {
+					g.Walkable[i] = false
+					g.Walkable[j] = false
+				}
+			}
+		}
+	}
+	return g
+}
+
+// ApplyMask overrides the walkability of the grid by the given authored mask,
+// stretched to cover the map area. Opaque white pixels mark walkable cells,
+// opaque black pixels mark blocked cells and transparent pixels leave the
+// derived walkability unchanged. Each cell is decided by the mask pixel at its
+// center.
+func (g *Grid) ApplyMask(mask image.Image) {
+	mb := mask.Bounds()
+	for row := 0; row < g.NRows; row++ {
+		for col := 0; col < g.NCols; col++ {
+			r := g.CellRect(col, row)
+			cx := (r.Min.X + r.Max.X) / 2
+			cy := (r.Min.Y + r.Max.Y) / 2
+			mx := mb.Min.X + (cx-g.Bounds.Min.X)*mb.Dx()/g.Bounds.Dx()
+			my := mb.Min.Y + (cy-g.Bounds.Min.Y)*mb.Dy()/g.Bounds.Dy()
+			c := color.NRGBAModel.Convert(mask.At(mx, my)).(color.NRGBA)
+			if c.A < 0x80 {
+				continue
+			}
+			gray := color.GrayModel.Convert(c).(color.Gray)
+			g.Walkable[g.index(col, row)] = gray.Y >= 0x80
+		}
+	}
+}
+
+// CellRect returns the bounds of the given cell in world coordinates, clipped
+// to the bounds of the map area.
+func (g *Grid) CellRect(col, row int) image.Rectangle {
+	x := g.Bounds.Min.X + col*g.CellSize
+	y := g.Bounds.Min.Y + row*g.CellSize
+	r := image.Rect(x, y, x+g.CellSize, y+g.CellSize)
+	return r.Intersect(g.Bounds)
+}
+
+// Cell returns the cell containing the given world position. The boolean
+// return value indicates if the position is within the bounds of the grid.
+func (g *Grid) Cell(x, y float64) (col, row int, ok bool) {
+	col = int(math.Floor((x - float64(g.Bounds.Min.X)) / float64(g.CellSize)))
+	row = int(math.Floor((y - float64(g.Bounds.Min.Y)) / float64(g.CellSize)))
+	return col, row, g.inside(col, row)
+}
+
+// CellWalkable reports whether the given cell is walkable. Cells outside of the
+// grid are not walkable.
+func (g *Grid) CellWalkable(col, row int) bool {
+	if !g.inside(col, row) {
+		return false
+	}
+	return g.Walkable[g.index(col, row)]
+}
+
+// IsWalkable reports whether the given world position is walkable.
+func (g *Grid) IsWalkable(x, y float64) bool {
+	col, row, ok := g.Cell(x, y)
+	if !ok {
+		return false
+	}
+	return g.Walkable[g.index(col, row)]
+}
+
+// SegmentBlocked reports whether the straight line segment between the given
+// world positions crosses a non-walkable cell.
+func (g *Grid) SegmentBlocked(x0, y0, x1, y1 float64) bool {
+	dist := math.Hypot(x1-x0, y1-y0)
+	// Sample the segment at intervals of a quarter cell.
+	n := int(math.Ceil(dist/(float64(g.CellSize)/4))) + 1
+	for i := 0; i <= n; i++ {
+		t := float64(i) / float64(n)
+		if !g.IsWalkable(x0+(x1-x0)*t, y0+(y1-y0)*t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Slide returns the part of the movement (dx, dy) from the given world position
+// that is not blocked; sliding along obstacles by dropping a blocked axis of
+// movement. Movement from non-walkable positions is never blocked, to let units
+// placed on blocked cells escape.
+func (g *Grid) Slide(x, y, dx, dy float64) (float64, float64) {
+	switch {
+	case !g.IsWalkable(x, y):
+		return dx, dy
+	case !g.SegmentBlocked(x, y, x+dx, y+dy):
+		return dx, dy
+	case dx != 0 && !g.SegmentBlocked(x, y, x+dx, y):
+		return dx, 0
+	case dy != 0 && !g.SegmentBlocked(x, y, x, y+dy):
+		return 0, dy
+	default:
+		return 0, 0
+	}
+}
+
+// Block marks the cells whose centers are within the given radius of the given
+// world position as non-walkable (e.g. cells covered by a prop).
+func (g *Grid) Block(x, y, radius float64) {
+	col0, row0, _ := g.Cell(x-radius, y-radius)
+	col1, row1, _ := g.Cell(x+radius, y+radius)
+	for row := row0; row <= row1; row++ {
+		for col := col0; col <= col1; col++ {
+			if !g.inside(col, row) {
+				continue
+			}
+			r := g.CellRect(col, row)
+			cx := float64(r.Min.X+r.Max.X) / 2
+			cy := float64(r.Min.Y+r.Max.Y) / 2
+			if math.Hypot(cx-x, cy-y) <= radius {
+				g.Walkable[g.index(col, row)] = false
+			}
+		}
+	}
+}
+
+// index returns the index of the given cell in the walkability slice.
+func (g *Grid) index(col, row int) int {
+	return row*g.NCols + col
+}
+
+// inside reports whether the given cell is within the grid.
+func (g *Grid) inside(col, row int) bool {
+	return col >= 0 && col < g.NCols && row >= 0 && row < g.NRows
+}
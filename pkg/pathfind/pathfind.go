@@ -0,0 +1,248 @@
+package pathfind
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/mewspring/ren/pkg/nav"
+)
+
+// Point is a position in world (area pixel) coordinates.
+type Point struct {
+	X, Y float64
+}
+
+// Find returns a path of waypoints from the start position to the goal
+// position, over the walkable cells of the given walkability grid. The path
+// excludes the start position and is smoothed to remove redundant waypoints.
+// If the goal is not walkable, the path leads to the nearest walkable cell
+// instead. The boolean return value indicates if a path was found; searches
+// giving up after expanding maxExpanded cells find no path.
+func Find(g *nav.Grid, start, goal Point) ([]Point, bool) {
+	sc, sr, ok := g.Cell(start.X, start.Y)
+	if !ok {
+		return nil, false
+	}
+	gc, gr, ok := g.Cell(goal.X, goal.Y)
+	if !ok {
+		return nil, false
+	}
+	if !g.CellWalkable(gc, gr) {
+		if gc, gr, ok = nearestWalkable(g, gc, gr); !ok {
+			return nil, false
+		}
+		// Head for the center of the nearest walkable cell.
+		goal = cellCenter(g, gc, gr)
+	}
+	cells, ok := astar(g, cell{sc, sr}, cell{gc, gr}, maxExpanded)
+	if !ok {
+		return nil, false
+	}
+	// Convert cells to waypoints, replacing the start and goal cells by the
+	// exact start and goal positions.
+	path := make([]Point, 0, len(cells)+1)
+	path = append(path, start)
+	for i := 1; i < len(cells)-1; i++ {
+		path = append(path, cellCenter(g, cells[i].col, cells[i].row))
+	}
+	path = append(path, goal)
+	path = Smooth(g, path)
+	return path[1:], true
+}
+
+// Smooth removes redundant waypoints from the given path, keeping only the
+// waypoints required to avoid obstacles of the walkability grid (i.e. string
+// pulling). The first and last waypoints are always kept.
+func Smooth(g *nav.Grid, path []Point) []Point {
+	if len(path) <= 2 {
+		return path
+	}
+	smooth := []Point{path[0]}
+	from := path[0]
+	for i := 1; i < len(path)-1; i++ {
+		next := path[i+1]
+		if g.SegmentBlocked(from.X, from.Y, next.X, next.Y) {
+			smooth = append(smooth, path[i])
+			from = path[i]
+		}
+	}
+	return append(smooth, path[len(path)-1])
+}
+
+// cell is a cell of a walkability grid.
+type cell struct {
+	col, row int
+}
+
+// node is a node of the A* open set.
+type node struct {
+	cell cell
+	// Cost of path from start to node.
+	g float64
+	// Estimated cost of path from start to goal through node.
+	f float64
+	// Index of node in priority queue.
+	index int
+}
+
+// maxExpanded is the maximum number of cells expanded by a path search; bounding
+// the cost of searches for unreachable goals, which would otherwise flood the
+// grid (e.g. each time a chasing unit repaths).
+const maxExpanded = 8192
+
+// astar returns the cells of the shortest 8-connected path between the start
+// and goal cells using A* search, expanding at most limit cells. Diagonal moves
+// may not cut corners of non-walkable cells. The boolean return value indicates
+// if a path was found.
+func astar(g *nav.Grid, start, goal cell, limit int) ([]cell, bool) {
+	open := &queue{}
+	nodes := map[cell]*node{}
+	from := map[cell]cell{}
+	closed := map[cell]bool{}
+	sn := &node{cell: start, f: heuristic(start, goal)}
+	nodes[start] = sn
+	heap.Push(open, sn)
+	for open.Len() > 0 {
+		n := heap.Pop(open).(*node)
+		if n.cell == goal {
+			return reconstruct(from, start, goal), true
+		}
+		if len(closed) >= limit {
+			return nil, false
+		}
+		closed[n.cell] = true
+		for _, d := range neighbours {
+			c := cell{n.cell.col + d.col, n.cell.row + d.row}
+			if closed[c] || !g.CellWalkable(c.col, c.row) {
+				continue
+			}
+			cost := 1.0
+			if d.col != 0 && d.row != 0 {
+				// Prevent cutting corners.
+				if !g.CellWalkable(n.cell.col+d.col, n.cell.row) || !g.CellWalkable(n.cell.col, n.cell.row+d.row) {
+					continue
+				}
+				cost = math.Sqrt2
+			}
+			gc := n.g + cost
+			if m, ok := nodes[c]; ok {
+				if gc >= m.g {
+					continue
+				}
+				m.g = gc
+				m.f = gc + heuristic(c, goal)
+				heap.Fix(open, m.index)
+			} else {
+				m := &node{cell: c, g: gc, f: gc + heuristic(c, goal)}
+				nodes[c] = m
+				heap.Push(open, m)
+			}
+			from[c] = n.cell
+		}
+	}
+	return nil, false
+}
+
+// neighbours specifies the offsets of the 8-connected neighbours of a cell.
+var neighbours = []cell{
+	{-1, -1}, {0, -1}, {1, -1},
+	{-1, 0}, {1, 0},
+	{-1, 1}, {0, 1}, {1, 1},
+}
+
+// heuristic returns the octile distance between the given cells.
+func heuristic(a, b cell) float64 {
+	dx := math.Abs(float64(a.col - b.col))
+	dy := math.Abs(float64(a.row - b.row))
+	return math.Max(dx, dy) + (math.Sqrt2-1)*math.Min(dx, dy)
+}
+
+// reconstruct returns the path of cells from start to goal.
+func reconstruct(from map[cell]cell, start, goal cell) []cell {
+	path := []cell{goal}
+	for c := goal; c != start; {
+		c = from[c]
+		path = append(path, c)
+	}
+	// Reverse path.
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// maxSearchRadius is the maximum distance in cells searched for the nearest
+// walkable cell.
+const maxSearchRadius = 32
+
+// nearestWalkable returns the walkable cell nearest to the given cell, within
+// maxSearchRadius cells. The boolean return value indicates if such a cell was
+// found.
+func nearestWalkable(g *nav.Grid, col, row int) (int, int, bool) {
+	for radius := 1; radius <= maxSearchRadius; radius++ {
+		best, bestDist := cell{}, math.Inf(1)
+		// Search the ring of cells at the given Chebyshev distance.
+		for dy := -radius; dy <= radius; dy++ {
+			for dx := -radius; dx <= radius; dx++ {
+				if abs(dx) != radius && abs(dy) != radius {
+					continue
+				}
+				c := cell{col + dx, row + dy}
+				if !g.CellWalkable(c.col, c.row) {
+					continue
+				}
+				if dist := math.Hypot(float64(dx), float64(dy)); dist < bestDist {
+					best, bestDist = c, dist
+				}
+			}
+		}
+		if !math.IsInf(bestDist, 1) {
+			return best.col, best.row, true
+		}
+	}
+	return 0, 0, false
+}
+
+// cellCenter returns the center of the given cell in world coordinates.
+func cellCenter(g *nav.Grid, col, row int) Point {
+	r := g.CellRect(col, row)
+	return Point{
+		X: float64(r.Min.X+r.Max.X) / 2,
+		Y: float64(r.Min.Y+r.Max.Y) / 2,
+	}
+}
+
+// abs returns the absolute value of x.
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// queue is a priority queue of A* nodes, ordered by estimated path cost.
+type queue []*node
+
+func (q queue) Len() int { return len(q) }
+
+func (q queue) Less(i, j int) bool { return q[i].f < q[j].f }
+
+func (q queue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *queue) Push(x interface{}) {
+	n := x.(*node)
+	n.index = len(*q)
+	*q = append(*q, n)
+}
+
+func (q *queue) Pop() interface{} {
+	old := *q
+	n := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return n
+}
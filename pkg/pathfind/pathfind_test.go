@@ -0,0 +1,220 @@
+package pathfind
+
+import (
+	"image"
+	"reflect"
+	"testing"
+
+	"github.com/mewspring/ren/pkg/nav"
+)
+
+// Width and height of grid cells of tests, in pixels.
+const cellSize = 10
+
+func TestFind(t *testing.T) {
+	golden := []struct {
+		name string
+		// Walkability grid; '#' marks non-walkable cells.
+		grid        []string
+		start, goal Point
+		want        []Point
+		ok          bool
+	}{
+		{
+			name:  "straight path",
+			grid:  []string{"......", "......"},
+			start: Point{5, 5},
+			goal:  Point{55, 5},
+			want:  []Point{{55, 5}},
+			ok:    true,
+		},
+		{
+			// Detour around the wall, through the gap at the bottom.
+			name:  "detour",
+			grid:  []string{"..#...", "..#...", "......"},
+			start: Point{5, 5},
+			goal:  Point{55, 5},
+			want:  []Point{{25, 25}, {55, 5}},
+			ok:    true,
+		},
+		{
+			name:  "unreachable goal",
+			grid:  []string{"..#...", "..#...", "..#..."},
+			start: Point{5, 5},
+			goal:  Point{55, 5},
+		},
+		{
+			// The goal on a blocked cell is replaced by the center of the nearest
+			// walkable cell.
+			name:  "blocked goal",
+			grid:  []string{"....##", "....##"},
+			start: Point{5, 5},
+			goal:  Point{58, 2},
+			want:  []Point{{35, 5}},
+			ok:    true,
+		},
+		{
+			name:  "start outside of grid",
+			grid:  []string{"......"},
+			start: Point{-5, 5},
+			goal:  Point{55, 5},
+		},
+	}
+	for _, g := range golden {
+		got, ok := Find(gridOf(g.grid...), g.start, g.goal)
+		if ok != g.ok {
+			t.Errorf("%s: path found mismatch; expected %v, got %v", g.name, g.ok, ok)
+			continue
+		}
+		if !reflect.DeepEqual(got, g.want) {
+			t.Errorf("%s: path mismatch; expected %v, got %v", g.name, g.want, got)
+		}
+	}
+}
+
+func TestAstar(t *testing.T) {
+	golden := []struct {
+		name        string
+		grid        []string
+		start, goal cell
+		limit       int
+		want        []cell
+		ok          bool
+	}{
+		{
+			name:  "diagonal",
+			grid:  []string{"...", "...", "..."},
+			start: cell{0, 0},
+			goal:  cell{2, 2},
+			limit: maxExpanded,
+			want:  []cell{{0, 0}, {1, 1}, {2, 2}},
+			ok:    true,
+		},
+		{
+			// Diagonal moves may not cut the corner of a blocked cell.
+			name:  "corner",
+			grid:  []string{".#.", "...", "..."},
+			start: cell{0, 0},
+			goal:  cell{1, 1},
+			limit: maxExpanded,
+			want:  []cell{{0, 0}, {0, 1}, {1, 1}},
+			ok:    true,
+		},
+		{
+			// Nor squeeze between two blocked cells.
+			name:  "blocked diagonal",
+			grid:  []string{".#.", "#..", "..."},
+			start: cell{0, 0},
+			goal:  cell{1, 1},
+			limit: maxExpanded,
+		},
+		{
+			// Searches give up after expanding the maximum number of cells.
+			name:  "expansion limit",
+			grid:  []string{"........", ".######.", "........"},
+			start: cell{0, 1},
+			goal:  cell{7, 1},
+			limit: 4,
+		},
+		{
+			name:  "within expansion limit",
+			grid:  []string{"........", ".######.", "........"},
+			start: cell{0, 1},
+			goal:  cell{7, 1},
+			limit: 20,
+			want:  []cell{{0, 1}, {0, 0}, {1, 0}, {2, 0}, {3, 0}, {4, 0}, {5, 0}, {6, 0}, {7, 0}, {7, 1}},
+			ok:    true,
+		},
+	}
+	for _, g := range golden {
+		got, ok := astar(gridOf(g.grid...), g.start, g.goal, g.limit)
+		if ok != g.ok {
+			t.Errorf("%s: path found mismatch; expected %v, got %v", g.name, g.ok, ok)
+			continue
+		}
+		if !reflect.DeepEqual(got, g.want) {
+			t.Errorf("%s: path mismatch; expected %v, got %v", g.name, g.want, got)
+		}
+	}
+}
+
+func TestAstarFlood(t *testing.T) {
+	// Searches stop after expanding maxExpanded cells, rather than flooding the
+	// grid; the goal at the end of a winding maze of 100 corridors is out of
+	// reach.
+	g := nav.NewGrid(image.Rect(0, 0, 200*cellSize, 100*cellSize), cellSize)
+	for col := 1; col < g.NCols; col += 2 {
+		// Walls with alternating gaps at the top and bottom.
+		gap := 0
+		if col%4 == 1 {
+			gap = g.NRows - 1
+		}
+		for row := 0; row < g.NRows; row++ {
+			if row != gap {
+				g.Walkable[row*g.NCols+col] = false
+			}
+		}
+	}
+	start, goal := cell{0, 0}, cell{g.NCols - 2, 0}
+	if _, ok := astar(g, start, goal, maxExpanded); ok {
+		t.Errorf("expected search to give up after expanding %d cells", maxExpanded)
+	}
+	if _, ok := astar(g, start, goal, len(g.Walkable)); !ok {
+		t.Errorf("expected path through maze without expansion limit")
+	}
+}
+
+func TestSmooth(t *testing.T) {
+	golden := []struct {
+		name string
+		grid []string
+		path []Point
+		want []Point
+	}{
+		{
+			name: "straight",
+			grid: []string{"....."},
+			path: []Point{{5, 5}, {15, 5}, {25, 5}, {35, 5}, {45, 5}},
+			want: []Point{{5, 5}, {45, 5}},
+		},
+		{
+			// The waypoint below the wall is kept, as the segment leading past it
+			// crosses the wall.
+			name: "wall",
+			grid: []string{"..#..", "..#..", "....."},
+			path: []Point{{5, 5}, {15, 15}, {25, 25}, {35, 15}, {45, 5}},
+			want: []Point{{5, 5}, {25, 25}, {45, 5}},
+		},
+		{
+			name: "short",
+			grid: []string{".."},
+			path: []Point{{5, 5}, {15, 5}},
+			want: []Point{{5, 5}, {15, 5}},
+		},
+	}
+	for _, g := range golden {
+		grid := gridOf(g.grid...)
+		got := Smooth(grid, g.path)
+		if !reflect.DeepEqual(got, g.want) {
+			t.Errorf("%s: path mismatch; expected %v, got %v", g.name, g.want, got)
+		}
+		for i := 1; i < len(got); i++ {
+			a, b := got[i-1], got[i]
+			if grid.SegmentBlocked(a.X, a.Y, b.X, b.Y) {
+				t.Errorf("%s: smoothed segment from %v to %v blocked", g.name, a, b)
+			}
+		}
+	}
+}
+
+// gridOf returns a walkability grid of the given rows, with '#' marking
+// non-walkable cells.
+func gridOf(rows ...string) *nav.Grid {
+	g := nav.NewGrid(image.Rect(0, 0, len(rows[0])*cellSize, len(rows)*cellSize), cellSize)
+	for row, s := range rows {
+		for col, c := range s {
+			g.Walkable[row*g.NCols+col] = c != '#'
+		}
+	}
+	return g
+}
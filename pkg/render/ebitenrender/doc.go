@@ -0,0 +1,6 @@
+// Package ebitenrender implements render targets drawing onto Ebiten images,
+// backed by a cache of GPU textures of source images.
+//
+// The package requires a display; it is excluded from builds using the headless
+// build tag (i.e. go build -tags headless).
+package ebitenrender
@@ -0,0 +1,135 @@
+//go:build !headless
+// +build !headless
+
+package ebitenrender
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/mewspring/ren/pkg/render"
+	"github.com/mewspring/ren/pkg/sim"
+	"github.com/pkg/errors"
+)
+
+// EbitenTarget is a render target drawing onto an Ebiten image.
+type EbitenTarget struct {
+	// Destination image.
+	Screen *ebiten.Image
+	// Source images uploaded to GPU.
+	Texs *TextureCache
+	// Color matrix applied to drawn regions (e.g. to blend overlays); the zero
+	// value is the identity.
+	ColorM ebiten.ColorM
+}
+
+// Draw performs the given draw operation on the render target.
+func (t *EbitenTarget) Draw(op render.Op) error {
+	src, err := t.Texs.Get(op)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !op.SrcRect.Empty() {
+		src = src.SubImage(op.SrcRect).(*ebiten.Image)
+	}
+	opt := &ebiten.DrawImageOptions{}
+	opt.GeoM.Scale(op.Scale, op.Scale)
+	opt.GeoM.Translate(op.X, op.Y)
+	opt.ColorM = t.ColorM
+	if err := t.Screen.DrawImage(src, opt); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// MaxTextureAge specifies the number of frames after which unused textures are
+// released.
+const MaxTextureAge = 2 * sim.TPS
+
+// TextureCache is a cache of GPU textures of source images. Textures are
+// uploaded on first use and released when unused for MaxTextureAge frames
+// (e.g. background tiles outside of the viewport).
+type TextureCache struct {
+	// Source images, indexed by name.
+	srcs map[string]image.Image
+	// Uploaded textures, indexed by name.
+	texs map[string]*texture
+	// Current frame number.
+	frame int
+}
+
+// texture is a GPU texture of a source image.
+type texture struct {
+	// Texture image.
+	img *ebiten.Image
+	// Revision of source image.
+	rev int
+	// Frame number of last use.
+	lastUsed int
+}
+
+// NewTextureCache returns a new texture cache for the given source images,
+// indexed by name.
+func NewTextureCache(srcs map[string]image.Image) *TextureCache {
+	return &TextureCache{
+		srcs: srcs,
+		texs: make(map[string]*texture),
+	}
+}
+
+// Get returns the texture of the source image of the given draw operation,
+// uploading it to GPU if not yet present or if its revision has changed.
+func (c *TextureCache) Get(op render.Op) (*ebiten.Image, error) {
+	tex, ok := c.texs[op.Src]
+	if ok && tex.rev == op.Rev {
+		tex.lastUsed = c.frame
+		return tex.img, nil
+	}
+	src := op.Img
+	if src == nil {
+		src, ok = c.srcs[op.Src]
+		if !ok {
+			return nil, errors.Errorf("unable to locate source image %q", op.Src)
+		}
+	}
+	if tex != nil {
+		// Replace texture of outdated revision.
+		if err := tex.img.Dispose(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	img, err := ebiten.NewImageFromImage(src, ebiten.FilterDefault)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	c.texs[op.Src] = &texture{img: img, rev: op.Rev, lastUsed: c.frame}
+	return img, nil
+}
+
+// ReleaseUnused releases the textures unused for MaxTextureAge frames and
+// advances the current frame number.
+func (c *TextureCache) ReleaseUnused() error {
+	for name, tex := range c.texs {
+		if c.frame-tex.lastUsed < MaxTextureAge {
+			continue
+		}
+		if err := tex.img.Dispose(); err != nil {
+			return errors.WithStack(err)
+		}
+		delete(c.texs, name)
+	}
+	c.frame++
+	return nil
+}
+
+// ReleaseAll releases all textures of the texture cache (e.g. when unloading a
+// map area).
+func (c *TextureCache) ReleaseAll() error {
+	for name, tex := range c.texs {
+		if err := tex.img.Dispose(); err != nil {
+			return errors.WithStack(err)
+		}
+		delete(c.texs, name)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+//go:build !headless
+// +build !headless
+
+package ebitenrender
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/mewspring/ren/pkg/light"
+	"github.com/mewspring/ren/pkg/render"
+	"github.com/pkg/errors"
+)
+
+// MaxShaderLights specifies the maximum number of point lights affecting a
+// region lit by the light shader; additional point lights are ignored.
+const MaxShaderLights = 16
+
+// lightShaderSrc is the Kage source of the light shader, lighting the
+// background (image 0) per pixel using the normals of the normal map (image 1).
+// The shading mirrors light.Scene.Shade and light.Apply.
+const lightShaderSrc = `package main
+
+// Screen position of the top-left corner of the drawn region.
+var ScreenPos vec2
+// Zoom factor; screen pixels per world pixel.
+var Zoom float
+// World position of the top-left corner of the drawn region.
+var Origin vec2
+// Ambient light.
+var Ambient vec3
+// Direction towards the directional light (normalized).
+var DirDir vec3
+// Color of the directional light, scaled by intensity.
+var DirColor vec3
+// Position (x, y, z) and radius (w) of point lights.
+var PointPos [16]vec4
+// Color of point lights, scaled by intensity.
+var PointColor [16]vec3
+
+func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+	bg := imageSrc0UnsafeAt(texCoord)
+	c := imageSrc1UnsafeAt(texCoord)
+	n := normalize(vec3(c.r*2-1, 1-c.g*2, c.b*2-1))
+	pos := Origin + (position.xy-ScreenPos)/Zoom
+	l := Ambient + DirColor*max(dot(n, DirDir), 0)
+	for i := 0; i < 16; i++ {
+		v := vec3(PointPos[i].xy-pos, PointPos[i].z)
+		dist := length(v)
+		if dist > 0 && dist < PointPos[i].w {
+			// Quadratic falloff towards the radius of the light.
+			att := 1 - dist/PointPos[i].w
+			l += PointColor[i] * max(dot(n, v)/dist, 0) * att * att
+		}
+	}
+	return vec4(min(bg.rgb*l, vec3(bg.a)), bg.a)
+}
+`
+
+// lightShader is the compiled light shader; compiled on first use.
+var lightShader *ebiten.Shader
+
+// DrawLit performs the given draw operation on the render target, lighting the
+// drawn region per pixel on the GPU by the given lighting, using the normals of
+// the source image of the normal draw operation; of the same size as the drawn
+// region. The top-left corner of the drawn region is at origin in world
+// coordinates.
+func (t *EbitenTarget) DrawLit(op, normal render.Op, origin image.Point, lighting *light.Scene) error {
+	if lightShader == nil {
+		s, err := ebiten.NewShader([]byte(lightShaderSrc))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		lightShader = s
+	}
+	src, err := t.Texs.Get(op)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	nrm, err := t.Texs.Get(normal)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	w, h := src.Size()
+	opt := &ebiten.DrawRectShaderOptions{}
+	opt.GeoM.Scale(op.Scale, op.Scale)
+	opt.GeoM.Translate(op.X, op.Y)
+	opt.Images[0] = src
+	opt.Images[1] = nrm
+	opt.Uniforms = lightUniforms(lighting)
+	opt.Uniforms["ScreenPos"] = []float32{float32(op.X), float32(op.Y)}
+	opt.Uniforms["Zoom"] = float32(op.Scale)
+	opt.Uniforms["Origin"] = []float32{float32(origin.X), float32(origin.Y)}
+	t.Screen.DrawRectShader(w, h, lightShader, opt)
+	return nil
+}
+
+// lightUniforms returns the uniform variables of the light shader for the
+// given lighting.
+func lightUniforms(s *light.Scene) map[string]interface{} {
+	dirDir := make([]float32, 3)
+	dirColor := make([]float32, 3)
+	if s.Dir != nil {
+		d := s.Dir.Dir.Normalize()
+		dirDir = []float32{float32(d.X), float32(d.Y), float32(d.Z)}
+		dirColor = colorUniform(s.Dir.Color, s.Dir.Intensity)
+	}
+	pointPos := make([]float32, 4*MaxShaderLights)
+	pointColor := make([]float32, 3*MaxShaderLights)
+	for i, p := range s.Points {
+		if i >= MaxShaderLights {
+			break
+		}
+		copy(pointPos[4*i:], []float32{float32(p.X), float32(p.Y), float32(p.Z), float32(p.Radius)})
+		copy(pointColor[3*i:], colorUniform(p.Color, p.Intensity))
+	}
+	return map[string]interface{}{
+		"Ambient":    colorUniform(s.Ambient, 1),
+		"DirDir":     dirDir,
+		"DirColor":   dirColor,
+		"PointPos":   pointPos,
+		"PointColor": pointColor,
+	}
+}
+
+// colorUniform returns the given light color scaled by intensity, as uniform
+// variable value.
+func colorUniform(c light.Color, intensity float64) []float32 {
+	return []float32{float32(c.R * intensity), float32(c.G * intensity), float32(c.B * intensity)}
+}
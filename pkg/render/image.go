@@ -0,0 +1,60 @@
+package render
+
+import (
+	"image"
+	"math"
+
+	"github.com/pkg/errors"
+	"golang.org/x/image/draw"
+)
+
+// ImageTarget is a render target drawing onto an in-memory RGBA image; for
+// use without a GPU or display (e.g. headless rendering and visual regression
+// tests).
+type ImageTarget struct {
+	// Destination image.
+	Dst *image.RGBA
+	// Source images, indexed by name.
+	Imgs map[string]image.Image
+}
+
+// NewImageTarget returns a new in-memory render target of the given size.
+func NewImageTarget(width, height int) *ImageTarget {
+	return &ImageTarget{
+		Dst:  image.NewRGBA(image.Rect(0, 0, width, height)),
+		Imgs: make(map[string]image.Image),
+	}
+}
+
+// Clear clears the destination image of the render target.
+func (t *ImageTarget) Clear() {
+	draw.Draw(t.Dst, t.Dst.Bounds(), image.Transparent, image.Point{}, draw.Src)
+}
+
+// Draw performs the given draw operation on the render target.
+func (t *ImageTarget) Draw(op Op) error {
+	src := op.Img
+	if src == nil {
+		var ok bool
+		src, ok = t.Imgs[op.Src]
+		if !ok {
+			return errors.Errorf("unable to locate source image %q", op.Src)
+		}
+	}
+	sr := op.SrcRect
+	if sr.Empty() {
+		sr = src.Bounds()
+	}
+	x := int(math.Round(op.X))
+	y := int(math.Round(op.Y))
+	if op.Scale == 1 {
+		dr := image.Rect(x, y, x+sr.Dx(), y+sr.Dy())
+		draw.Draw(t.Dst, dr, src, sr.Min, draw.Over)
+		return nil
+	}
+	w := int(math.Round(float64(sr.Dx()) * op.Scale))
+	h := int(math.Round(float64(sr.Dy()) * op.Scale))
+	dr := image.Rect(x, y, x+w, y+h)
+	draw.ApproxBiLinear.Scale(t.Dst, dr, src, sr, draw.Over, nil)
+	return nil
+}
@@ -0,0 +1,73 @@
+package render
+
+import (
+	"image"
+
+	"github.com/mewspring/ren/pkg/assets"
+	"github.com/mewspring/ren/pkg/camera"
+	"github.com/mewspring/ren/pkg/sim"
+	"github.com/pkg/errors"
+)
+
+// WalkabilityImage is the source image name of the walkability grid of the
+// current map area.
+const WalkabilityImage = "walkability"
+
+// layerImage returns the layer of the map area drawn instead of the background
+// layer. The boolean return value indicates if such a layer is selected.
+func (r *Renderer) layerImage(world *sim.World) (image.Image, bool) {
+	var img image.Image
+	switch r.Layer {
+	case assets.LayerKindNormal:
+		img = world.Area.NormalLayer
+	case assets.LayerKindHeight:
+		img = world.Area.HeightLayer
+	case assets.LayerKindAS:
+		img = world.Area.ASLayer
+	}
+	return img, img != nil
+}
+
+// layerRect returns the region of the given layer covering the region r of the
+// background layer with the given bounds, and the scale factor from layer
+// pixels to background pixels. Layers may have a lower resolution than the
+// background layer (e.g. the half resolution normal layer of dump_layers).
+func layerRect(layer image.Image, bg, r image.Rectangle) (image.Rectangle, float64) {
+	lb := layer.Bounds()
+	if lb.Dx() == 0 || lb.Dy() == 0 {
+		return image.Rectangle{}, 1
+	}
+	scale := float64(bg.Dx()) / float64(lb.Dx())
+	lr := image.Rect(
+		lb.Min.X+(r.Min.X-bg.Min.X)*lb.Dx()/bg.Dx(),
+		lb.Min.Y+(r.Min.Y-bg.Min.Y)*lb.Dy()/bg.Dy(),
+		lb.Min.X+(r.Max.X-bg.Min.X)*lb.Dx()/bg.Dx(),
+		lb.Min.Y+(r.Max.Y-bg.Min.Y)*lb.Dy()/bg.Dy(),
+	)
+	return lr, scale
+}
+
+// DrawWalkability draws the walkability grid of the world onto the render
+// target, as seen by the given camera; white for walkable and black for blocked
+// cells.
+func (r *Renderer) DrawWalkability(t Target, world *sim.World, cam *camera.Camera) error {
+	g := world.Nav
+	if r.nav != g {
+		r.nav = g
+		r.navImg = g.Image()
+		r.navRev = r.nextRev()
+	}
+	x, y := cam.WorldToScreen(float64(g.Bounds.Min.X), float64(g.Bounds.Min.Y))
+	op := Op{
+		Src:   WalkabilityImage,
+		Img:   r.navImg,
+		Rev:   r.navRev,
+		X:     x,
+		Y:     y,
+		Scale: cam.Zoom * float64(g.CellSize),
+	}
+	if err := t.Draw(op); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package render
+
+import (
+	"image"
+
+	"github.com/mewspring/ren/pkg/light"
+	"github.com/mewspring/ren/pkg/sim"
+	"golang.org/x/image/draw"
+)
+
+// litTile is a background tile lit by the lighting of a world.
+type litTile struct {
+	// Lit tile image.
+	img *image.RGBA
+	// Lighting affecting the tile at the time of lighting.
+	lighting light.Scene
+	// Revision of tile image; updated on each relighting.
+	rev int
+}
+
+// litTile returns the given background tile (col, row) with bounds tr, lit by
+// the lighting of the world. The tile is only relit if the lights affecting the
+// tile have changed since last use.
+func (r *Renderer) litTile(world *sim.World, tile image.Point, tr image.Rectangle) *litTile {
+	lighting := affecting(world.Lighting, tr)
+	lt, ok := r.lit[tile]
+	if ok && sameLighting(&lt.lighting, &lighting) {
+		return lt
+	}
+	if !ok {
+		lt = &litTile{
+			img: image.NewRGBA(tr),
+		}
+		r.lit[tile] = lt
+	}
+	area := world.Area
+	light.Apply(lt.img, area.BackgroundLayer, area.NormalLayer, &lighting)
+	lt.lighting = lighting
+	lt.rev = r.nextRev()
+	return lt
+}
+
+// normalTile returns the region of the normal layer of the current map area
+// covering the given background tile (col, row) with bounds tr, stretched to
+// the resolution of the background layer if needed.
+func (r *Renderer) normalTile(world *sim.World, tile image.Point, tr image.Rectangle) image.Image {
+	if img, ok := r.normals[tile]; ok {
+		return img
+	}
+	area := world.Area
+	lr, scale := layerRect(area.NormalLayer, area.BackgroundLayer.Bounds(), tr)
+	var img image.Image
+	if scale == 1 {
+		img = subImage(area.NormalLayer, lr)
+	} else {
+		dst := image.NewRGBA(tr)
+		draw.NearestNeighbor.Scale(dst, tr, area.NormalLayer, lr, draw.Src, nil)
+		img = dst
+	}
+	r.normals[tile] = img
+	return img
+}
+
+// releaseLitTiles releases the lit background tiles and normal layer tiles not
+// among the given visible tiles.
+func (r *Renderer) releaseLitTiles(visible []image.Point) {
+	keep := make(map[image.Point]bool)
+	for _, tile := range visible {
+		keep[tile] = true
+	}
+	for tile := range r.lit {
+		if !keep[tile] {
+			delete(r.lit, tile)
+		}
+	}
+	for tile := range r.normals {
+		if !keep[tile] {
+			delete(r.normals, tile)
+		}
+	}
+}
+
+// affecting returns the subset of the given lighting that affects the region
+// tr.
+func affecting(s *light.Scene, tr image.Rectangle) light.Scene {
+	sub := light.Scene{
+		Ambient: s.Ambient,
+		Dir:     s.Dir,
+	}
+	for _, p := range s.Points {
+		if p.Bounds().Overlaps(tr) {
+			sub.Points = append(sub.Points, p)
+		}
+	}
+	return sub
+}
+
+// sameLighting reports whether the lightings a and b are identical.
+func sameLighting(a, b *light.Scene) bool {
+	if a.Ambient != b.Ambient {
+		return false
+	}
+	if (a.Dir == nil) != (b.Dir == nil) {
+		return false
+	}
+	if a.Dir != nil && *a.Dir != *b.Dir {
+		return false
+	}
+	if len(a.Points) != len(b.Points) {
+		return false
+	}
+	for i := range a.Points {
+		if a.Points[i] != b.Points[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+package render
+
+import (
+	"image"
+	"math"
+
+	"github.com/mewspring/ren/pkg/depth"
+	"github.com/mewspring/ren/pkg/entity"
+	"github.com/mewspring/ren/pkg/sim"
+	"github.com/pkg/errors"
+)
+
+// occludedFrame is a unit frame with the pixels hidden behind scenery cleared.
+type occludedFrame struct {
+	// Occluded frame image, positioned in background layer coordinates.
+	img *image.RGBA
+	// Frame of sprite sheet at the time of occlusion.
+	frame image.Rectangle
+	// Ground contact point of unit at the time of occlusion.
+	ground image.Point
+	// Revision of frame image; updated on each occlusion.
+	rev int
+}
+
+// occludedFrame returns the current frame fr of the given unit, occluded by the
+// scenery in front of the unit. The frame is only recomputed if the frame or
+// the position of the unit has changed since last use.
+func (r *Renderer) occludedFrame(world *sim.World, unit *entity.Unit, fr image.Rectangle) (*occludedFrame, error) {
+	area := world.Area
+	if r.depth == nil || r.depth.Height != area.HeightLayer {
+		r.depth = depth.New(area.HeightLayer, area.BackgroundLayer.Bounds())
+	}
+	ground := image.Pt(int(math.Round(unit.X)), int(math.Round(unit.Y)))
+	of, ok := r.occluded[unit.ID]
+	if ok && of.frame == fr && of.ground == ground {
+		return of, nil
+	}
+	sheetImg, ok := r.Imgs[unit.Type.SheetPath]
+	if !ok {
+		return nil, errors.Errorf("unable to locate sprite sheet %q of unit type %q", unit.Type.SheetPath, unit.Type.Name)
+	}
+	sheet := unit.Type.Sheet
+	min := ground.Sub(image.Pt(sheet.OffsetX, sheet.OffsetY))
+	dr := image.Rectangle{Min: min, Max: min.Add(fr.Size())}
+	if of == nil {
+		of = &occludedFrame{}
+		r.occluded[unit.ID] = of
+	}
+	if of.img == nil || of.img.Bounds() != dr {
+		of.img = image.NewRGBA(dr)
+	}
+	r.depth.Occlude(of.img, subImage(sheetImg, fr), float64(ground.Y))
+	of.frame = fr
+	of.ground = ground
+	of.rev = r.nextRev()
+	return of, nil
+}
+
+// releaseOccluded releases the occluded frames of units no longer present in
+// the given world.
+func (r *Renderer) releaseOccluded(world *sim.World) {
+	for id := range r.occluded {
+		if _, ok := world.Units.ByID(id); !ok {
+			delete(r.occluded, id)
+		}
+	}
+}
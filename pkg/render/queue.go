@@ -0,0 +1,92 @@
+package render
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Kind specifies the kind of a drawable.
+type Kind uint8
+
+// Drawable kinds, in draw order of drawables at equal depth.
+const (
+	// Static prop (e.g. barrel, crate).
+	KindProp Kind = iota + 1
+	// Unit (e.g. monster).
+	KindUnit
+	// Effect (e.g. projectile, spell).
+	KindEffect
+)
+
+// Drawable is an object of the world drawn in depth order.
+type Drawable struct {
+	// Depth of the drawable; the y-coordinate of its ground contact point in
+	// world coordinates.
+	Depth float64
+	// X-coordinate of the ground contact point in world coordinates.
+	X float64
+	// Kind of drawable.
+	Kind Kind
+	// ID of drawable; unique among drawables of the same kind.
+	ID int
+	// Draw draws the drawable onto the render target.
+	Draw func(t Target) error
+}
+
+// Queue is a render queue, drawing drawables back to front by the depth of
+// their ground contact point. Ties are broken deterministically by kind,
+// x-coordinate and ID.
+type Queue struct {
+	// Queued drawables.
+	items []Drawable
+}
+
+// Push adds the given drawable to the render queue.
+func (q *Queue) Push(d Drawable) {
+	q.items = append(q.items, d)
+}
+
+// Len returns the number of queued drawables.
+func (q *Queue) Len() int {
+	return len(q.items)
+}
+
+// Flush draws the queued drawables onto the render target back to front, and
+// empties the queue; also if drawing fails, so that failed frames do not leak
+// drawables into the next frame.
+func (q *Queue) Flush(t Target) error {
+	defer q.reset()
+	sort.Slice(q.items, func(i, j int) bool {
+		return less(q.items[i], q.items[j])
+	})
+	for _, d := range q.items {
+		if err := d.Draw(t); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// reset empties the queue, reusing queue memory and releasing references to
+// drawables.
+func (q *Queue) reset() {
+	for i := range q.items {
+		q.items[i] = Drawable{}
+	}
+	q.items = q.items[:0]
+}
+
+// less reports whether a is drawn before b.
+func less(a, b Drawable) bool {
+	if a.Depth != b.Depth {
+		return a.Depth < b.Depth
+	}
+	if a.Kind != b.Kind {
+		return a.Kind < b.Kind
+	}
+	if a.X != b.X {
+		return a.X < b.X
+	}
+	return a.ID < b.ID
+}
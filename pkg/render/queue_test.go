@@ -0,0 +1,112 @@
+package render_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/mewspring/ren/pkg/render"
+)
+
+func TestQueueOrder(t *testing.T) {
+	golden := []struct {
+		name string
+		// Drawables in push order.
+		items []render.Drawable
+		// Names of drawables in expected draw order.
+		want []string
+	}{
+		{
+			name: "depth",
+			items: []render.Drawable{
+				{Depth: 20, Kind: render.KindUnit, ID: 1},
+				{Depth: 10, Kind: render.KindUnit, ID: 2},
+			},
+			want: []string{"unit 2", "unit 1"},
+		},
+		{
+			// Props are drawn before units, and units before effects.
+			name: "kind",
+			items: []render.Drawable{
+				{Depth: 10, Kind: render.KindEffect, ID: 1},
+				{Depth: 10, Kind: render.KindUnit, ID: 1},
+				{Depth: 10, Kind: render.KindProp, ID: 1},
+			},
+			want: []string{"prop 1", "unit 1", "effect 1"},
+		},
+		{
+			name: "x-coordinate",
+			items: []render.Drawable{
+				{Depth: 10, X: 30, Kind: render.KindUnit, ID: 1},
+				{Depth: 10, X: 20, Kind: render.KindUnit, ID: 2},
+			},
+			want: []string{"unit 2", "unit 1"},
+		},
+		{
+			name: "ID",
+			items: []render.Drawable{
+				{Depth: 10, X: 20, Kind: render.KindUnit, ID: 3},
+				{Depth: 10, X: 20, Kind: render.KindUnit, ID: 1},
+				{Depth: 10, X: 20, Kind: render.KindUnit, ID: 2},
+			},
+			want: []string{"unit 1", "unit 2", "unit 3"},
+		},
+	}
+	for _, g := range golden {
+		// Draw order is independent of push order.
+		for _, reverse := range []bool{false, true} {
+			q := &render.Queue{}
+			var got []string
+			for i := range g.items {
+				if reverse {
+					i = len(g.items) - 1 - i
+				}
+				d := g.items[i]
+				name := fmt.Sprintf("%s %d", kindName(d.Kind), d.ID)
+				d.Draw = func(render.Target) error {
+					got = append(got, name)
+					return nil
+				}
+				q.Push(d)
+			}
+			if err := q.Flush(nil); err != nil {
+				t.Errorf("%s: unable to flush render queue; %+v", g.name, err)
+				continue
+			}
+			if !reflect.DeepEqual(got, g.want) {
+				t.Errorf("%s: draw order mismatch (reverse push order: %v); expected %v, got %v", g.name, reverse, g.want, got)
+			}
+		}
+	}
+}
+
+func TestQueueFlushError(t *testing.T) {
+	// Failed flushes empty the queue.
+	q := &render.Queue{}
+	q.Push(render.Drawable{Depth: 10, Kind: render.KindUnit, ID: 1, Draw: func(render.Target) error {
+		return errors.New("draw failed")
+	}})
+	q.Push(render.Drawable{Depth: 20, Kind: render.KindUnit, ID: 2, Draw: func(render.Target) error {
+		return nil
+	}})
+	if err := q.Flush(nil); err == nil {
+		t.Errorf("expected error flushing render queue")
+	}
+	if q.Len() != 0 {
+		t.Errorf("queue length mismatch; expected 0, got %d", q.Len())
+	}
+}
+
+// kindName returns the name of the given drawable kind.
+func kindName(kind render.Kind) string {
+	switch kind {
+	case render.KindProp:
+		return "prop"
+	case render.KindUnit:
+		return "unit"
+	case render.KindEffect:
+		return "effect"
+	}
+	return fmt.Sprintf("kind %d", kind)
+}
@@ -0,0 +1,312 @@
+package render
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/mewspring/ren/pkg/assets"
+	"github.com/mewspring/ren/pkg/camera"
+	"github.com/mewspring/ren/pkg/depth"
+	"github.com/mewspring/ren/pkg/entity"
+	"github.com/mewspring/ren/pkg/light"
+	"github.com/mewspring/ren/pkg/nav"
+	"github.com/mewspring/ren/pkg/sim"
+	"github.com/pkg/errors"
+)
+
+// Target is a render target (e.g. an Ebiten screen or an in-memory image).
+type Target interface {
+	// Draw performs the given draw operation on the render target.
+	Draw(op Op) error
+}
+
+// LightTarget is a render target lighting drawn regions itself (e.g. on the GPU
+// using a shader), instead of drawing regions lit on the CPU.
+type LightTarget interface {
+	Target
+	// DrawLit performs the given draw operation on the render target, lighting
+	// the drawn region per pixel by the given lighting, using the normals of the
+	// source image of the normal draw operation; of the same size as the drawn
+	// region. The top-left corner of the drawn region is at origin in world
+	// coordinates.
+	DrawLit(op, normal Op, origin image.Point, lighting *light.Scene) error
+}
+
+// Op is a draw operation, drawing a region of a named source image onto the
+// render target.
+type Op struct {
+	// Name of source image (e.g. BackgroundImage or sprite sheet path).
+	Src string
+	// Source image generated while rendering (e.g. lit background tile); drawn
+	// instead of the named source image if non-nil.
+	Img image.Image
+	// Revision of Img; incremented whenever the contents of Img change.
+	Rev int
+	// Region of source image to draw; or the entire source image if empty.
+	SrcRect image.Rectangle
+	// Position of the top-left corner of the drawn region, in screen pixel
+	// coordinates.
+	X, Y float64
+	// Scale factor of drawn region.
+	Scale float64
+}
+
+// BackgroundImage is the source image name of the background layer of the
+// current map area. The background layer is drawn in tiles, named by TileName.
+const BackgroundImage = "background"
+
+// LitBackgroundImage is the source image name of the lit background layer of
+// the current map area, drawn in tiles named by TileName.
+const LitBackgroundImage = "lit_background"
+
+// LitNormalImage is the source image name of the normal layer of the current
+// map area used for lighting, at the resolution of the background layer and
+// drawn in tiles named by TileName.
+const LitNormalImage = "lit_normal"
+
+// OccludedUnitImage is the source image name prefix of occluded unit frames,
+// named "occluded_unit-ID" by unit ID.
+const OccludedUnitImage = "occluded_unit"
+
+// Renderer renders worlds onto render targets.
+type Renderer struct {
+	// Specifies whether to light the background layer by the lighting of the
+	// world.
+	//
+	// Render targets implementing LightTarget light the background on the GPU.
+	// Otherwise (e.g. headless rendering and tests), lit tiles are computed on
+	// the CPU as reference implementation (see light.Apply) and only relit when
+	// the lights affecting them change.
+	Lighting bool
+	// Specifies whether to occlude units behind scenery, using the height layer
+	// of the map area.
+	Occlusion bool
+	// Layer of the map area drawn instead of the background layer (e.g. the
+	// height layer, to inspect layers); or 0 to draw the background layer.
+	Layer assets.LayerKind
+	// Specifies whether to draw the walkability grid of the world instead of the
+	// background layer.
+	Walkability bool
+	// Source images, indexed by name; used to compute images while rendering
+	// (e.g. occluded unit frames).
+	Imgs map[string]image.Image
+	// Lit background tiles, indexed by tile (col, row).
+	lit map[image.Point]*litTile
+	// Normal layer tiles at the resolution of the background layer, indexed by
+	// tile (col, row).
+	normals map[image.Point]image.Image
+	// Depth map of current map area.
+	depth *depth.Map
+	// Occluded unit frames, indexed by unit ID.
+	occluded map[int]*occludedFrame
+	// Render queue of units, props and effects.
+	queue Queue
+	// Selection circle image.
+	circle *image.RGBA
+	// Walkability grid of the walkability image.
+	nav *nav.Grid
+	// Walkability image, with one pixel per cell.
+	navImg image.Image
+	// Revision of walkability image.
+	navRev int
+	// Latest revision of images generated while rendering (e.g. lit tiles);
+	// shared by all generated images, so that revisions are never reused, not
+	// even by images recreated after being released.
+	rev int
+}
+
+// NewRenderer returns a new renderer, using the given source images indexed by
+// name.
+func NewRenderer(imgs map[string]image.Image) *Renderer {
+	return &Renderer{
+		Imgs:     imgs,
+		lit:      make(map[image.Point]*litTile),
+		normals:  make(map[image.Point]image.Image),
+		occluded: make(map[int]*occludedFrame),
+	}
+}
+
+// nextRev returns a new revision for an image generated while rendering.
+func (r *Renderer) nextRev() int {
+	r.rev++
+	return r.rev
+}
+
+// DrawWorld draws the background of the current map area and the props, units
+// and projectiles of the given world onto the render target, as seen by the
+// given camera.
+func (r *Renderer) DrawWorld(t Target, world *sim.World, cam *camera.Camera) error {
+	if err := r.DrawBackground(t, world, cam); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := r.DrawSelection(t, world, cam); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, prop := range world.Props {
+		prop := prop
+		d := Drawable{
+			Depth: prop.Y,
+			X:     prop.X,
+			Kind:  KindProp,
+			ID:    prop.ID,
+			Draw: func(t Target) error {
+				return r.DrawProp(t, prop, cam)
+			},
+		}
+		r.queue.Push(d)
+	}
+	for _, unit := range world.Units.Units {
+		unit := unit
+		d := Drawable{
+			Depth: unit.Y,
+			X:     unit.X,
+			Kind:  KindUnit,
+			ID:    unit.ID,
+			Draw: func(t Target) error {
+				return r.DrawUnit(t, world, unit, cam)
+			},
+		}
+		r.queue.Push(d)
+	}
+	for _, p := range world.Projectiles {
+		p := p
+		d := Drawable{
+			Depth: p.Y,
+			X:     p.X,
+			Kind:  KindEffect,
+			ID:    p.ID,
+			Draw: func(t Target) error {
+				return r.DrawProjectile(t, p, cam)
+			},
+		}
+		r.queue.Push(d)
+	}
+	if err := r.queue.Flush(t); err != nil {
+		return errors.WithStack(err)
+	}
+	r.releaseOccluded(world)
+	return nil
+}
+
+// DrawBackground draws the tiles of the background layer of the current map
+// area that intersect the viewport of the given camera onto the render target.
+// The selected layer or walkability grid is drawn instead if present (see
+// Renderer.Layer and Renderer.Walkability).
+func (r *Renderer) DrawBackground(t Target, world *sim.World, cam *camera.Camera) error {
+	if r.Walkability && world.Nav != nil {
+		return r.DrawWalkability(t, world, cam)
+	}
+	layer, hasLayer := r.layerImage(world)
+	g := TileGrid{
+		Bounds: world.Area.BackgroundLayer.Bounds(),
+		Size:   TileSize,
+	}
+	tiles := g.Tiles(cam.Viewport())
+	for _, tile := range tiles {
+		tr := g.TileRect(tile)
+		x, y := cam.WorldToScreen(float64(tr.Min.X), float64(tr.Min.Y))
+		op := Op{
+			Src:   TileName(BackgroundImage, tile),
+			X:     x,
+			Y:     y,
+			Scale: cam.Zoom,
+		}
+		switch {
+		case hasLayer:
+			lr, scale := layerRect(layer, g.Bounds, tr)
+			op.Src = TileName(assets.LayerKindName(r.Layer), tile)
+			op.Img = subImage(layer, lr)
+			op.Scale = cam.Zoom * scale
+		case r.Lighting && world.Lighting != nil:
+			if lt, ok := t.(LightTarget); ok {
+				lighting := affecting(world.Lighting, tr)
+				normal := Op{
+					Src: TileName(LitNormalImage, tile),
+					Img: r.normalTile(world, tile, tr),
+				}
+				if err := lt.DrawLit(op, normal, tr.Min, &lighting); err != nil {
+					return errors.WithStack(err)
+				}
+				continue
+			}
+			lt := r.litTile(world, tile, tr)
+			op.Src = TileName(LitBackgroundImage, tile)
+			op.Img = lt.img
+			op.Rev = lt.rev
+		}
+		if err := t.Draw(op); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	r.releaseLitTiles(tiles)
+	return nil
+}
+
+// DrawUnit draws the current animation frame of the given unit onto the render
+// target, as seen by the given camera.
+func (r *Renderer) DrawUnit(t Target, world *sim.World, unit *entity.Unit, cam *camera.Camera) error {
+	fr, ok := unit.Frame()
+	if !ok {
+		return nil
+	}
+	x, y := cam.WorldToScreen(unit.DrawPos())
+	op := Op{
+		Src:     unit.Type.SheetPath,
+		SrcRect: fr,
+		X:       x,
+		Y:       y,
+		Scale:   cam.Zoom,
+	}
+	if r.Occlusion {
+		of, err := r.occludedFrame(world, unit, fr)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		op.Src = fmt.Sprintf("%s-%d", OccludedUnitImage, unit.ID)
+		op.Img = of.img
+		op.Rev = of.rev
+		op.SrcRect = image.Rectangle{}
+	}
+	if err := t.Draw(op); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// DrawProjectile draws the current animation frame of the given projectile onto
+// the render target, as seen by the given camera. Projectiles fly above scenery
+// and are never occluded.
+func (r *Renderer) DrawProjectile(t Target, p *entity.Projectile, cam *camera.Camera) error {
+	fr, ok := p.Frame()
+	if !ok {
+		return nil
+	}
+	x, y := cam.WorldToScreen(p.DrawPos())
+	op := Op{
+		Src:     p.Type.SheetPath,
+		SrcRect: fr,
+		X:       x,
+		Y:       y,
+		Scale:   cam.Zoom,
+	}
+	if err := t.Draw(op); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// DrawProp draws the given prop onto the render target, as seen by the given
+// camera.
+func (r *Renderer) DrawProp(t Target, prop *entity.Prop, cam *camera.Camera) error {
+	x, y := cam.WorldToScreen(prop.DrawPos())
+	op := Op{
+		Src:   prop.ImagePath,
+		X:     x,
+		Y:     y,
+		Scale: cam.Zoom,
+	}
+	if err := t.Draw(op); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
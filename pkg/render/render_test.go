@@ -0,0 +1,283 @@
+package render_test
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mewspring/ren/pkg/camera"
+	"github.com/mewspring/ren/pkg/entity"
+	"github.com/mewspring/ren/pkg/light"
+	"github.com/mewspring/ren/pkg/render"
+	"github.com/mewspring/ren/pkg/sim"
+	"github.com/mewspring/ren/pkg/sim/simtest"
+)
+
+// update specifies whether to update the golden images of testdata.
+var update = flag.Bool("update", false, "update golden images")
+
+// Screen dimensions of rendered frames.
+const (
+	screenWidth  = 320
+	screenHeight = 240
+)
+
+func TestDrawWorld(t *testing.T) {
+	world, imgs := testWorld()
+	player := simtest.SpawnPlayer(world, simtest.UnitType("hero"), 512, 384)
+	simtest.Spawn(world, simtest.UnitType("monster"), entity.FactionMonster, 560, 400)
+	r := render.NewRenderer(imgs)
+	got := drawWorld(t, r, world)
+	golden(t, "world.golden.png", got)
+	// The ground contact point of the player unit is drawn at the center of the
+	// screen, which the camera is centered on.
+	fr, _ := player.Frame()
+	sheet := imgs[player.Type.SheetPath]
+	ox, oy := player.Type.Sheet.OffsetX, player.Type.Sheet.OffsetY
+	want := sheet.At(fr.Min.X+ox, fr.Min.Y+oy)
+	if c := got.At(screenWidth/2, screenHeight/2); !sameColor(c, want) {
+		t.Errorf("player pixel mismatch; expected %v, got %v", want, c)
+	}
+}
+
+func TestDrawWorldLit(t *testing.T) {
+	world, imgs := testWorld()
+	simtest.SpawnPlayer(world, simtest.UnitType("hero"), 512, 384)
+	world.Lighting = testLighting()
+	r := render.NewRenderer(imgs)
+	r.Lighting = true
+	got := drawWorld(t, r, world)
+	golden(t, "world_lit.golden.png", got)
+	// Ground beneath the torch is lit brighter than the unlit background, and
+	// ground outside of its radius darker.
+	bg := world.Area.BackgroundLayer
+	lit, unlit := got.RGBAAt(screenWidth/2+20, screenHeight/2), bg.At(512+20, 384).(color.RGBA)
+	if lit.R <= unlit.R {
+		t.Errorf("expected ground beneath torch lit brighter than %v, got %v", unlit, lit)
+	}
+	dark, unlit := got.RGBAAt(0, 0), bg.At(512-screenWidth/2, 384-screenHeight/2).(color.RGBA)
+	if dark.R >= unlit.R {
+		t.Errorf("expected ground outside of torch lit darker than %v, got %v", unlit, dark)
+	}
+}
+
+func TestDrawLit(t *testing.T) {
+	// Render targets lighting drawn regions themselves are given the lighting
+	// affecting each background tile, and the normals to light it by.
+	world, imgs := testWorld()
+	world.Lighting = testLighting()
+	r := render.NewRenderer(imgs)
+	r.Lighting = true
+	target := &lightTarget{ImageTarget: render.NewImageTarget(screenWidth, screenHeight)}
+	target.Imgs = imgs
+	cam := camera.New(screenWidth, screenHeight, world.Area.BackgroundLayer.Bounds())
+	if err := r.DrawBackground(target, world, cam); err != nil {
+		t.Fatalf("unable to draw background; %+v", err)
+	}
+	// The viewport spans the two tiles left and right of x = 512.
+	if len(target.lit) != 2 {
+		t.Fatalf("lit tile count mismatch; expected 2, got %d", len(target.lit))
+	}
+	for _, l := range target.lit {
+		if l.op.Img != nil {
+			t.Errorf("%s: expected background tile drawn unlit by name, got image", l.op.Src)
+		}
+		nb := l.normal.Img.Bounds()
+		tr := imgs[l.op.Src].Bounds()
+		if nb != tr {
+			t.Errorf("%s: normal bounds mismatch; expected %v, got %v", l.op.Src, tr, nb)
+		}
+		if l.origin != tr.Min {
+			t.Errorf("%s: origin mismatch; expected %v, got %v", l.op.Src, tr.Min, l.origin)
+		}
+		if len(l.lighting.Points) != 1 {
+			t.Errorf("%s: point light count mismatch; expected 1, got %d", l.op.Src, len(l.lighting.Points))
+		}
+	}
+}
+
+func TestLitTileRev(t *testing.T) {
+	// Lit tiles recreated after being released (e.g. when scrolled out of and
+	// back into view) are of a new revision, so that stale textures of the tile
+	// are not reused.
+	world, imgs := testWorld()
+	world.Lighting = testLighting()
+	r := render.NewRenderer(imgs)
+	r.Lighting = true
+	target := &recordTarget{ImageTarget: render.NewImageTarget(screenWidth, screenHeight)}
+	target.Imgs = imgs
+	cam := camera.New(screenWidth, screenHeight, world.Area.BackgroundLayer.Bounds())
+	name := render.TileName(render.LitBackgroundImage, image.Pt(0, 0))
+	revs := make(map[int]bool)
+	for _, x := range []float64{256, 768, 256} {
+		cam.CenterOn(x, 256)
+		if err := r.DrawBackground(target, world, cam); err != nil {
+			t.Fatalf("unable to draw background; %+v", err)
+		}
+		for _, op := range target.ops {
+			if op.Src == name {
+				revs[op.Rev] = true
+			}
+		}
+		target.ops = nil
+	}
+	if len(revs) != 2 {
+		t.Errorf("revision count mismatch of %s; expected 2, got %d", name, len(revs))
+	}
+}
+
+// recordTarget is a render target recording draw operations.
+type recordTarget struct {
+	*render.ImageTarget
+	// Draw operations.
+	ops []render.Op
+}
+
+// Draw records and performs the given draw operation.
+func (t *recordTarget) Draw(op render.Op) error {
+	t.ops = append(t.ops, op)
+	return t.ImageTarget.Draw(op)
+}
+
+// lightTarget is a render target recording lit draw operations.
+type lightTarget struct {
+	*render.ImageTarget
+	// Lit draw operations.
+	lit []litOp
+}
+
+// litOp is a lit draw operation.
+type litOp struct {
+	op, normal render.Op
+	origin     image.Point
+	lighting   light.Scene
+}
+
+// DrawLit records the given lit draw operation.
+func (t *lightTarget) DrawLit(op, normal render.Op, origin image.Point, lighting *light.Scene) error {
+	t.lit = append(t.lit, litOp{op: op, normal: normal, origin: origin, lighting: *lighting})
+	return nil
+}
+
+// testLighting returns a dim lighting with a torch at the center of the map area
+// of testWorld.
+func testLighting() *light.Scene {
+	return &light.Scene{
+		Ambient: light.Color{R: 0.5, G: 0.5, B: 0.5},
+		Points: []light.PointLight{
+			{X: 512, Y: 384, Z: 40, Radius: 100, Color: light.Color{R: 1, G: 0.8, B: 0.5}, Intensity: 2},
+		},
+	}
+}
+
+// testWorld returns a world of a patterned 1024x768 map area, and the source
+// images to render it.
+func testWorld() (*sim.World, map[string]image.Image) {
+	world := simtest.World(1024, 768)
+	// Half resolution normal layer facing the viewer.
+	normal := image.NewRGBA(image.Rect(0, 0, 512, 384))
+	draw.Draw(normal, normal.Bounds(), image.NewUniform(color.RGBA{R: 128, G: 128, B: 255, A: 255}), image.Point{}, draw.Src)
+	world.Area.NormalLayer = normal
+	bg := world.Area.BackgroundLayer.(*image.RGBA)
+	b := bg.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.RGBA{R: uint8(x / 4), G: uint8(y / 3), B: 0x40, A: 0xFF}
+			if (x/32+y/32)%2 == 0 {
+				c.B = 0x80
+			}
+			bg.SetRGBA(x, y, c)
+		}
+	}
+	imgs := render.SplitTiles(render.BackgroundImage, bg, render.TileSize)
+	imgs["hero.png"] = sheetImage(color.RGBA{R: 0xFF, A: 0xFF})
+	imgs["monster.png"] = sheetImage(color.RGBA{G: 0xFF, A: 0xFF})
+	return world, imgs
+}
+
+// sheetImage returns a sprite sheet image of the unit type of simtest, with
+// frames of distinct shades of the given color.
+func sheetImage(c color.RGBA) *image.RGBA {
+	const (
+		frameSize = 8
+		nframes   = 17
+		nrows     = 8
+	)
+	img := image.NewRGBA(image.Rect(0, 0, nframes*frameSize, nrows*frameSize))
+	for row := 0; row < nrows; row++ {
+		for frame := 0; frame < nframes; frame++ {
+			fc := c
+			fc.B = uint8(row*nframes + frame)
+			fr := image.Rect(frame*frameSize, row*frameSize, (frame+1)*frameSize, (row+1)*frameSize)
+			for y := fr.Min.Y; y < fr.Max.Y; y++ {
+				for x := fr.Min.X; x < fr.Max.X; x++ {
+					img.SetRGBA(x, y, fc)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// drawWorld renders the given world to an in-memory image, as seen by a camera
+// centered on the map area.
+func drawWorld(t *testing.T, r *render.Renderer, world *sim.World) *image.RGBA {
+	t.Helper()
+	target := render.NewImageTarget(screenWidth, screenHeight)
+	target.Imgs = r.Imgs
+	cam := camera.New(screenWidth, screenHeight, world.Area.BackgroundLayer.Bounds())
+	if err := r.DrawWorld(target, world, cam); err != nil {
+		t.Fatalf("unable to draw world; %+v", err)
+	}
+	return target.Dst
+}
+
+// golden compares the given image against the golden image of the given name in
+// testdata; or stores it as the golden image if the -update flag is set.
+func golden(t *testing.T, name string, got *image.RGBA) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatalf("unable to create golden image; %v", err)
+		}
+		defer f.Close()
+		if err := png.Encode(f, got); err != nil {
+			t.Fatalf("unable to encode golden image; %v", err)
+		}
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("unable to open golden image; %v", err)
+	}
+	defer f.Close()
+	want, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("unable to decode golden image %q; %v", path, err)
+	}
+	if want.Bounds() != got.Bounds() {
+		t.Fatalf("%s: bounds mismatch; expected %v, got %v", name, want.Bounds(), got.Bounds())
+	}
+	b := got.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if w, g := want.At(x, y), got.At(x, y); !sameColor(w, g) {
+				t.Fatalf("%s: pixel mismatch at (%d, %d); expected %v, got %v", name, x, y, w, g)
+			}
+		}
+	}
+}
+
+// sameColor reports whether the colors a and b are identical.
+func sameColor(a, b color.Color) bool {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	return ar == br && ag == bg && ab == bb && aa == ba
+}
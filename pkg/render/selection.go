@@ -0,0 +1,74 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/mewspring/ren/pkg/camera"
+	"github.com/mewspring/ren/pkg/sim"
+	"github.com/pkg/errors"
+)
+
+// SelectionImage is the source image name of selection circles.
+const SelectionImage = "selection"
+
+// Selection circle dimensions in pixels.
+const (
+	selectionWidth  = 96
+	selectionHeight = 48
+)
+
+// selectionColor is the color of selection circles.
+var selectionColor = color.RGBA{R: 0x30, G: 0xD0, B: 0x30, A: 0xFF}
+
+// DrawSelection draws selection circles beneath the selected units of the given
+// world onto the render target, as seen by the given camera.
+func (r *Renderer) DrawSelection(t Target, world *sim.World, cam *camera.Camera) error {
+	if r.circle == nil {
+		r.circle = selectionCircle(selectionWidth, selectionHeight, selectionColor)
+	}
+	for _, unit := range world.SelectedUnits() {
+		x, y := cam.WorldToScreen(unit.X-selectionWidth/2, unit.Y-selectionHeight/2)
+		op := Op{
+			Src:   SelectionImage,
+			Img:   r.circle,
+			X:     x,
+			Y:     y,
+			Scale: cam.Zoom,
+		}
+		if err := t.Draw(op); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// selectionCircle returns an image of a selection circle of the given size and
+// color; an ellipse ring, as circles on the ground appear in isometric view.
+func selectionCircle(width, height int, c color.RGBA) *image.RGBA {
+	const thickness = 2.5
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	a := float64(width)/2 - 1
+	b := float64(height)/2 - 1
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// Approximate distance in pixels to the ellipse, by scaling the
+			// normalized radial distance by the minor semi-axis.
+			dx := (float64(x) + 0.5 - float64(width)/2) / a
+			dy := (float64(y) + 0.5 - float64(height)/2) / b
+			dist := math.Abs(math.Hypot(dx, dy)-1) * b
+			if dist >= thickness {
+				continue
+			}
+			alpha := 1 - dist/thickness
+			img.SetRGBA(x, y, color.RGBA{
+				R: uint8(float64(c.R) * alpha),
+				G: uint8(float64(c.G) * alpha),
+				B: uint8(float64(c.B) * alpha),
+				A: uint8(float64(c.A) * alpha),
+			})
+		}
+	}
+	return img
+}
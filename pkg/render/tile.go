@@ -0,0 +1,80 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+)
+
+// TileSize is the width and height in pixels of the tiles of the background
+// layer; chosen well below the texture size limits of GPUs.
+const TileSize = 512
+
+// TileGrid is a grid of fixed-size tiles covering an image.
+type TileGrid struct {
+	// Bounds of the tiled image.
+	Bounds image.Rectangle
+	// Width and height of tiles in pixels.
+	Size int
+}
+
+// TileRect returns the bounds of the given tile (col, row), clipped to the
+// bounds of the tiled image.
+func (g TileGrid) TileRect(tile image.Point) image.Rectangle {
+	x := g.Bounds.Min.X + tile.X*g.Size
+	y := g.Bounds.Min.Y + tile.Y*g.Size
+	r := image.Rect(x, y, x+g.Size, y+g.Size)
+	return r.Intersect(g.Bounds)
+}
+
+// Tiles returns the tiles (col, row) intersecting the given rectangle, in
+// row-major order.
+func (g TileGrid) Tiles(r image.Rectangle) []image.Point {
+	r = r.Intersect(g.Bounds)
+	if r.Empty() {
+		return nil
+	}
+	minCol := (r.Min.X - g.Bounds.Min.X) / g.Size
+	minRow := (r.Min.Y - g.Bounds.Min.Y) / g.Size
+	maxCol := (r.Max.X - g.Bounds.Min.X - 1) / g.Size
+	maxRow := (r.Max.Y - g.Bounds.Min.Y - 1) / g.Size
+	var tiles []image.Point
+	for row := minRow; row <= maxRow; row++ {
+		for col := minCol; col <= maxCol; col++ {
+			tiles = append(tiles, image.Pt(col, row))
+		}
+	}
+	return tiles
+}
+
+// TileName returns the source image name of the given tile (col, row) of the
+// named source image.
+func TileName(src string, tile image.Point) string {
+	// background-R000_C000
+	return fmt.Sprintf("%s-R%03d_C%03d", src, tile.Y, tile.X)
+}
+
+// SplitTiles splits the given image into tiles of the specified size. The
+// returned tiles are indexed by tile name (see TileName).
+func SplitTiles(src string, img image.Image, size int) map[string]image.Image {
+	g := TileGrid{Bounds: img.Bounds(), Size: size}
+	tiles := make(map[string]image.Image)
+	for _, tile := range g.Tiles(g.Bounds) {
+		r := g.TileRect(tile)
+		tiles[TileName(src, tile)] = subImage(img, r)
+	}
+	return tiles
+}
+
+// subImage returns the region r of the given image, sharing pixels with the
+// original image if supported by the image type.
+func subImage(img image.Image, r image.Rectangle) image.Image {
+	if img, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	}); ok {
+		return img.SubImage(r)
+	}
+	dst := image.NewRGBA(r)
+	draw.Draw(dst, r, img, r.Min, draw.Src)
+	return dst
+}
@@ -0,0 +1,135 @@
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/mewspring/ren/pkg/save"
+	"github.com/mewspring/ren/pkg/sim"
+	"github.com/pkg/errors"
+)
+
+// Version is the current version of the recording format.
+const Version = 1
+
+// Recording is a recorded play session; the start state of the game and the
+// player input of each simulation tick.
+//
+// Recordings are stored as JSON Lines files, with the header on the first line
+// followed by one line per tick. Ticks are appended as they are recorded, so
+// that a crashed session leaves a replayable recording.
+type Recording struct {
+	// Recording header.
+	Header
+	// Recorded simulation ticks, in order.
+	Ticks []Tick
+}
+
+// Header is the header of a recording.
+type Header struct {
+	// Recording format version.
+	Version int `json:"version"`
+	// Seed of the random number generator of the world at the start of the
+	// recording. Worlds of later map areas are seeded by the random number
+	// generator of the previous world.
+	Seed int64 `json:"seed"`
+	// Game state at the start of the recording, including the scene file of the
+	// starting map area.
+	Start *save.Save `json:"start"`
+}
+
+// Tick is a recorded simulation tick.
+type Tick struct {
+	// Specifies whether the simulation was paused.
+	Paused bool `json:"paused,omitempty"`
+	// Player input of the tick.
+	Input sim.Input `json:"input"`
+	// Checksum of the simulation state after the tick.
+	Checksum uint64 `json:"checksum"`
+}
+
+// Recorder records play sessions to file.
+type Recorder struct {
+	// Recording file.
+	f *os.File
+	// JSON encoder of recording file.
+	enc *json.Encoder
+}
+
+// Create creates a recording file at the given path, starting from the given
+// game state and seed.
+func Create(path string, seed int64, start *save.Save) (*Recorder, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	r := &Recorder{
+		f:   f,
+		enc: json.NewEncoder(f),
+	}
+	start.Version = save.Version
+	hdr := Header{
+		Version: Version,
+		Seed:    seed,
+		Start:   start,
+	}
+	if err := r.enc.Encode(hdr); err != nil {
+		f.Close()
+		return nil, errors.WithStack(err)
+	}
+	return r, nil
+}
+
+// Record records the given simulation tick.
+func (r *Recorder) Record(tick Tick) error {
+	if err := r.enc.Encode(tick); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// Close closes the recording file.
+func (r *Recorder) Close() error {
+	if err := r.f.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// Read reads the recording of the given JSON Lines file.
+func Read(path string) (*Recording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	dec := json.NewDecoder(bufio.NewReader(f))
+	rec := &Recording{}
+	if err := dec.Decode(&rec.Header); err != nil {
+		return nil, errors.Wrapf(err, "unable to decode header of recording %q", path)
+	}
+	if rec.Version != Version {
+		return nil, errors.Errorf("unsupported version %d of recording %q; expected %d", rec.Version, path, Version)
+	}
+	if rec.Start == nil {
+		return nil, errors.Errorf("missing start state of recording %q", path)
+	}
+	for dec.More() {
+		var tick Tick
+		if err := dec.Decode(&tick); err != nil {
+			// Tolerate a truncated last line of a crashed session.
+			if err == io.ErrUnexpectedEOF {
+				break
+			}
+			return nil, errors.Wrapf(err, "unable to decode tick %d of recording %q", len(rec.Ticks), path)
+		}
+		rec.Ticks = append(rec.Ticks, tick)
+	}
+	return rec, nil
+}
@@ -0,0 +1,167 @@
+package replay_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mewspring/ren/pkg/ai"
+	"github.com/mewspring/ren/pkg/entity"
+	"github.com/mewspring/ren/pkg/replay"
+	"github.com/mewspring/ren/pkg/save"
+	"github.com/mewspring/ren/pkg/sim"
+	"github.com/mewspring/ren/pkg/sim/simtest"
+)
+
+// Seed of recorded sessions.
+const seed = 42
+
+// Number of recorded ticks; ten seconds of play.
+const nticks = 10 * sim.TPS
+
+func TestRoundTrip(t *testing.T) {
+	// Record a session.
+	dir, err := ioutil.TempDir("", "replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "session.rec")
+	w := testWorld(seed)
+	r, err := replay.Create(path, seed, &save.Save{Area: w.Area.Name})
+	if err != nil {
+		t.Fatalf("unable to create recording; %+v", err)
+	}
+	var sums []uint64
+	for i := 0; i < nticks; i++ {
+		paused := i >= 300 && i < 330
+		in := testInput(w, i)
+		w.Paused = paused
+		w.Update(in)
+		sum := w.Checksum()
+		sums = append(sums, sum)
+		if err := r.Record(replay.Tick{Paused: paused, Input: in, Checksum: sum}); err != nil {
+			t.Fatalf("unable to record tick %d; %+v", i, err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("unable to close recording; %+v", err)
+	}
+
+	// Replay the recording.
+	rec, err := replay.Read(path)
+	if err != nil {
+		t.Fatalf("unable to read recording; %+v", err)
+	}
+	if rec.Seed != seed {
+		t.Errorf("seed mismatch; expected %d, got %d", seed, rec.Seed)
+	}
+	if len(rec.Ticks) != nticks {
+		t.Fatalf("tick count mismatch; expected %d, got %d", nticks, len(rec.Ticks))
+	}
+	if i, ok := replayWorld(testWorld(rec.Seed), rec); !ok {
+		t.Fatalf("desync at tick %d", i)
+	}
+	for i, tick := range rec.Ticks {
+		if tick.Checksum != sums[i] {
+			t.Fatalf("checksum mismatch of tick %d; expected %016x, got %016x", i, sums[i], tick.Checksum)
+		}
+	}
+
+	// Replays of diverging worlds are detected; wandering monsters of other
+	// seeds take other paths.
+	if _, ok := replayWorld(testWorld(seed+1), rec); ok {
+		t.Errorf("expected desync of world of other seed")
+	}
+}
+
+func TestReadTruncated(t *testing.T) {
+	// Recordings of crashed sessions are replayable up to the last complete
+	// tick.
+	dir, err := ioutil.TempDir("", "replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "session.rec")
+	r, err := replay.Create(path, seed, &save.Save{Area: "test"})
+	if err != nil {
+		t.Fatalf("unable to create recording; %+v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := r.Record(replay.Tick{Input: sim.Input{MoveX: 1}, Checksum: uint64(i)}); err != nil {
+			t.Fatalf("unable to record tick %d; %+v", i, err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("unable to close recording; %+v", err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(path, fi.Size()-5); err != nil {
+		t.Fatal(err)
+	}
+	rec, err := replay.Read(path)
+	if err != nil {
+		t.Fatalf("unable to read truncated recording; %+v", err)
+	}
+	if len(rec.Ticks) != 2 {
+		t.Errorf("tick count mismatch; expected 2, got %d", len(rec.Ticks))
+	}
+}
+
+// testWorld returns a world seeded by the given seed, of a player unit, an ally
+// and wandering monsters.
+func testWorld(seed int64) *sim.World {
+	w := simtest.World(1024, 768)
+	w.Reseed(seed)
+	simtest.SpawnPlayer(w, simtest.UnitType("hero"), 200, 200)
+	simtest.Spawn(w, simtest.UnitType("hero"), entity.FactionPlayer, 240, 200)
+	for _, pos := range [][2]float64{{400, 300}, {600, 500}, {800, 200}} {
+		monster := simtest.Spawn(w, simtest.UnitType("monster"), entity.FactionMonster, pos[0], pos[1])
+		w.Controllers = append(w.Controllers, ai.NewBrain(monster, ai.DefaultConfig()))
+	}
+	return w
+}
+
+// testInput returns the player input of the given tick of recorded sessions;
+// moving, attacking, selecting and ordering units.
+func testInput(w *sim.World, tick int) sim.Input {
+	var in sim.Input
+	switch {
+	case tick < 60:
+		in.MoveX = 1
+	case tick < 120:
+		in.MoveX, in.MoveY = 1, 1
+	case tick == 120:
+		var ids []int
+		for _, unit := range w.Units.Units {
+			if unit.Faction == entity.FactionPlayer {
+				ids = append(ids, unit.ID)
+			}
+		}
+		in.Select = &sim.Select{IDs: ids}
+	case tick == 121:
+		in.MoveTo, in.DestX, in.DestY = true, 400, 300
+	case tick%90 == 0:
+		in.Attack, in.TargetX, in.TargetY = true, 400, 300
+	}
+	return in
+}
+
+// replayWorld replays the given recording on the given world. The boolean
+// return value indicates if the checksum of each tick matched the recording;
+// or else the integer return value is the first mismatched tick.
+func replayWorld(w *sim.World, rec *replay.Recording) (int, bool) {
+	for i, tick := range rec.Ticks {
+		w.Paused = tick.Paused
+		w.Update(tick.Input)
+		if w.Checksum() != tick.Checksum {
+			return i, false
+		}
+	}
+	return 0, true
+}
@@ -0,0 +1,284 @@
+package save
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Version is the current version of the save game format.
+//
+// Saves of older versions are upgraded by the registered migrations on load.
+// Fields added in later versions must be optional, so that saves of newer
+// versions can be loaded on a best-effort basis; unknown fields are ignored.
+const Version = 2
+
+// Save is a saved game; the state of a play session.
+type Save struct {
+	// Save game format version.
+	Version int `json:"version"`
+	// Name of current map area.
+	Area string `json:"area"`
+	// Path to scene file of current map area; or empty if the scene of the map
+	// area is located by area name (see scene.LoadArea).
+	Scene string `json:"scene,omitempty"`
+	// Number of simulation ticks since start of simulation.
+	Tick int `json:"tick"`
+	// Specifies whether the simulation is paused.
+	Paused bool `json:"paused,omitempty"`
+	// Seed of the random number generator of the simulation.
+	Seed int64 `json:"seed"`
+	// State of the random number generator of the simulation (see
+	// sim.World.RandState); restored on load, so that the simulation continues
+	// as it would have without saving.
+	RandState uint64 `json:"rand_state"`
+	// Camera of current map area.
+	Camera Camera `json:"camera"`
+	// ID of unit controlled by the player; or 0 if none.
+	PlayerID int `json:"player_id"`
+	// IDs of selected units, in order of selection.
+	Selected []int `json:"selected,omitempty"`
+	// Units of current map area.
+	Units []Unit `json:"units"`
+	// Projectiles in flight.
+	Projectiles []Projectile `json:"projectiles,omitempty"`
+	// AI brains of current map area.
+	Brains []Brain `json:"brains,omitempty"`
+	// Explored cells of the fog of war of current map area (see
+	// minimap.Fog.Bitmap).
+	Explored []byte `json:"explored,omitempty"`
+	// Persistent state of visited map areas, indexed by area name.
+	Areas map[string]Area `json:"areas,omitempty"`
+}
+
+// Camera is the saved state of a camera.
+type Camera struct {
+	// Position of the camera center, in area pixel coordinates.
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	// Zoom factor.
+	Zoom float64 `json:"zoom"`
+}
+
+// Unit is the saved state of a unit.
+type Unit struct {
+	// Unit ID.
+	ID int `json:"id"`
+	// Unit type name.
+	Type string `json:"type"`
+	// Index of scene spawn of unit; or -1 if not spawned by the scene.
+	Spawn int `json:"spawn"`
+	// Position, in area pixel coordinates.
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	// Facing direction (e.g. "S").
+	Facing string `json:"facing"`
+	// Faction (e.g. "player").
+	Faction string `json:"faction"`
+	// Current health.
+	HP int `json:"hp"`
+	// Animation state.
+	Anim Anim `json:"anim"`
+	// Remaining waypoints of path.
+	Path []Point `json:"path,omitempty"`
+	// ID of attacked unit; or 0 if none.
+	Target int `json:"target,omitempty"`
+	// Target position of ranged attack.
+	AimX float64 `json:"aim_x,omitempty"`
+	AimY float64 `json:"aim_y,omitempty"`
+	// ID of unit which last damaged the unit; or 0 if none.
+	Attacker int `json:"attacker,omitempty"`
+}
+
+// Anim is the saved state of an animation controller.
+type Anim struct {
+	// Animation state (e.g. "run").
+	State string `json:"state"`
+	// Current frame of animation.
+	Frame int `json:"frame"`
+	// Frame number increment of back-and-forth animations.
+	Inc int `json:"inc,omitempty"`
+	// Time elapsed since last frame update, in nanoseconds.
+	Elapsed int64 `json:"elapsed,omitempty"`
+}
+
+// Point is a position in area pixel coordinates.
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Projectile is the saved state of a projectile.
+type Projectile struct {
+	// Projectile ID.
+	ID int `json:"id"`
+	// Projectile type name.
+	Type string `json:"type"`
+	// ID of unit which fired the projectile.
+	OwnerID int `json:"owner_id"`
+	// Faction of unit which fired the projectile.
+	Faction string `json:"faction"`
+	// Position, in area pixel coordinates.
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	// Unit vector of flight direction.
+	DX float64 `json:"dx"`
+	DY float64 `json:"dy"`
+	// Distance travelled in pixels.
+	Travelled float64 `json:"travelled"`
+}
+
+// Brain is the saved state of an AI brain.
+type Brain struct {
+	// ID of controlled unit.
+	UnitID int `json:"unit_id"`
+	// Behaviour state (e.g. "wander").
+	State string `json:"state"`
+	// Home position, in area pixel coordinates.
+	HomeX float64 `json:"home_x"`
+	HomeY float64 `json:"home_y"`
+	// Number of ticks left to idle before wandering.
+	IdleTicks int `json:"idle_ticks,omitempty"`
+	// Behaviour parameters.
+	AggroRadius float64 `json:"aggro_radius"`
+	LeashRadius float64 `json:"leash_radius"`
+	Wander      bool    `json:"wander"`
+	// Idle durations in nanoseconds.
+	MinIdle    int64   `json:"min_idle"`
+	MaxIdle    int64   `json:"max_idle"`
+	FleeHealth float64 `json:"flee_health"`
+	FleeDist   float64 `json:"flee_dist"`
+}
+
+// Area is the saved persistent state of a visited map area.
+type Area struct {
+	// Indices of scene spawns whose units are dead.
+	Dead []int `json:"dead,omitempty"`
+	// Explored cells of the fog of war of the map area (see
+	// minimap.Fog.Bitmap).
+	Explored []byte `json:"explored,omitempty"`
+}
+
+// Migration upgrades the raw JSON object of a save game from one version to
+// the next. The version field is updated by the caller.
+type Migration func(m map[string]interface{}) error
+
+// Migrations holds the migrations of save games, indexed by the version they
+// upgrade from.
+var Migrations = map[int]Migration{
+	1: migrateRandState,
+}
+
+// migrateRandState upgrades save games of version 1, which lack the state of
+// the random number generator, by resetting the generator to the default seed
+// of new worlds.
+func migrateRandState(m map[string]interface{}) error {
+	m["seed"] = 1
+	m["rand_state"] = 1
+	return nil
+}
+
+// Read reads the save game of the given JSON file, upgrading it to the current
+// version.
+func Read(path string) (*Save, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	s, err := Decode(buf)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to decode save game %q", path)
+	}
+	return s, nil
+}
+
+// Decode decodes the given JSON encoded save game, upgrading it to the current
+// version.
+func Decode(buf []byte) (*Save, error) {
+	// Decode numbers as json.Number, to retain the precision of 64-bit integers
+	// (e.g. the random number generator state) when re-encoding.
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+	var m map[string]interface{}
+	if err := dec.Decode(&m); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	n, ok := m["version"].(json.Number)
+	if !ok {
+		return nil, errors.New("missing save game version")
+	}
+	v, err := n.Int64()
+	if err != nil {
+		return nil, errors.Errorf("invalid save game version %q", n)
+	}
+	version := int(v)
+	for ; version < Version; version++ {
+		migrate, ok := Migrations[version]
+		if !ok {
+			return nil, errors.Errorf("unable to upgrade save game from version %d; no migration present", version)
+		}
+		if err := migrate(m); err != nil {
+			return nil, errors.Wrapf(err, "unable to upgrade save game from version %d", version)
+		}
+		m["version"] = version + 1
+	}
+	// Re-encode the upgraded save game to decode it into its Go representation.
+	buf, err = json.Marshal(m)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	s := &Save{}
+	if err := json.Unmarshal(buf, s); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return s, nil
+}
+
+// Write writes the save game to the given JSON file. The file is replaced
+// atomically, so that a failed write keeps the previous save game.
+func Write(path string, s *Save) error {
+	s.Version = Version
+	buf, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, buf, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// SlotPath returns the path to the save game of the given slot name (e.g.
+// "quicksave") within the save slot directory.
+func SlotPath(dir, slot string) string {
+	return filepath.Join(dir, fmt.Sprintf("%s.json", slot))
+}
+
+// Slots returns the names of the save slots within the given save slot
+// directory, in alphabetical order.
+func Slots(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var slots []string
+	for _, match := range matches {
+		slots = append(slots, strings.TrimSuffix(filepath.Base(match), ".json"))
+	}
+	sort.Strings(slots)
+	return slots, nil
+}
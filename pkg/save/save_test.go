@@ -0,0 +1,93 @@
+package save_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mewspring/ren/pkg/save"
+)
+
+func TestDecode(t *testing.T) {
+	golden := []struct {
+		name string
+		buf  string
+		want *save.Save
+	}{
+		{
+			// Saves of version 1 lack the random number generator state, which is
+			// reset to the default seed of new worlds.
+			name: "version 1",
+			buf:  `{"version": 1, "area": "town", "tick": 120, "player_id": 1, "units": [{"id": 1, "type": "balrog", "spawn": 0, "x": 300, "y": 300, "facing": "S", "faction": "player", "hp": 100, "anim": {"state": "stance"}}]}`,
+			want: &save.Save{
+				Version:   save.Version,
+				Area:      "town",
+				Tick:      120,
+				Seed:      1,
+				RandState: 1,
+				PlayerID:  1,
+				Units:     []save.Unit{{ID: 1, Type: "balrog", X: 300, Y: 300, Facing: "S", Faction: "player", HP: 100, Anim: save.Anim{State: "stance"}}},
+			},
+		},
+		{
+			// 64-bit integers retain their precision when re-encoded after
+			// migration.
+			name: "version 2",
+			buf:  `{"version": 2, "area": "town", "seed": 1602777123456789123, "rand_state": 18446744073709551557}`,
+			want: &save.Save{
+				Version:   save.Version,
+				Area:      "town",
+				Seed:      1602777123456789123,
+				RandState: 18446744073709551557,
+			},
+		},
+	}
+	for _, g := range golden {
+		got, err := save.Decode([]byte(g.buf))
+		if err != nil {
+			t.Errorf("%s: unable to decode save game; %+v", g.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, g.want) {
+			t.Errorf("%s: save game mismatch; expected %+v, got %+v", g.name, g.want, got)
+		}
+	}
+}
+
+func TestDecodeMigrations(t *testing.T) {
+	// Saves are upgraded one version at a time, in order.
+	var got []int
+	for version := 0; version < save.Version; version++ {
+		version := version
+		old := save.Migrations[version]
+		save.Migrations[version] = func(m map[string]interface{}) error {
+			got = append(got, version)
+			if old != nil {
+				return old(m)
+			}
+			return nil
+		}
+		defer func() {
+			if old == nil {
+				delete(save.Migrations, version)
+			} else {
+				save.Migrations[version] = old
+			}
+		}()
+	}
+	s, err := save.Decode([]byte(`{"version": 0, "area": "town"}`))
+	if err != nil {
+		t.Fatalf("unable to decode save game; %+v", err)
+	}
+	if want := []int{0, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("migrations mismatch; expected %v, got %v", want, got)
+	}
+	if s.Version != save.Version || s.Area != "town" {
+		t.Errorf("save game mismatch; expected version %d of area %q, got version %d of area %q", save.Version, "town", s.Version, s.Area)
+	}
+
+	// Saves without migration from their version are rejected.
+	delete(save.Migrations, 0)
+	if _, err := save.Decode([]byte(`{"version": 0, "area": "town"}`)); err == nil {
+		t.Errorf("expected error decoding save game without migration")
+	}
+}
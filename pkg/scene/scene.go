@@ -0,0 +1,257 @@
+package scene
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/mewspring/ren/pkg/ai"
+	"github.com/mewspring/ren/pkg/assets"
+	"github.com/mewspring/ren/pkg/facing"
+	"github.com/mewspring/ren/pkg/light"
+	"github.com/pkg/errors"
+)
+
+// Scene is an area scene; the layers, unit spawns, props, lights, entry points
+// and exit regions of a map area. Scenes are stored as JSON files; e.g.
+//
+//	{
+//	   "area": "yenwood",
+//	   "spawns": [
+//	      {"type": "balrog", "x": 760, "y": 760, "faction": "player", "player": true},
+//	      {"type": "balrog", "x": 1060, "y": 860, "facing": "W", "ai": "monster"}
+//	   ],
+//	   "props": [
+//	      {"name": "crate", "image": "props/crate.png", "x": 900, "y": 700, "offset_x": 32, "offset_y": 48, "radius": 24}
+//	   ],
+//	   "lighting": {
+//	      "ambient": {"r": 0.45, "g": 0.45, "b": 0.5},
+//	      "points": [{"x": 1200, "y": 900, "z": 80, "radius": 300, "color": {"r": 1, "g": 0.7, "b": 0.4}, "intensity": 1}]
+//	   },
+//	   "entries": [
+//	      {"name": "west", "x": 200, "y": 1000, "facing": "E"}
+//	   ],
+//	   "exits": [
+//	      {"name": "to_town", "rect": {"x0": 0, "y0": 900, "x1": 64, "y1": 1100}, "dest": "town", "entry": "east"}
+//	   ]
+//	}
+type Scene struct {
+	// Area name.
+	Area string `json:"area"`
+	// Layer file names relative to the assets directory, indexed by layer name
+	// (e.g. "background", "height"); layers not present use their default file
+	// names (e.g. "yenwood_background.png").
+	Layers map[string]string `json:"layers,omitempty"`
+	// Unit spawns.
+	Spawns []Spawn `json:"spawns,omitempty"`
+	// Static props.
+	Props []Prop `json:"props,omitempty"`
+	// Lighting of the area; or nil for default lighting.
+	Lighting *light.Scene `json:"lighting,omitempty"`
+	// Entry points of the area.
+	Entries []Entry `json:"entries,omitempty"`
+	// Exit regions of the area.
+	Exits []Exit `json:"exits,omitempty"`
+}
+
+// Spawn is a unit spawn of a scene.
+type Spawn struct {
+	// Unit type name (e.g. "balrog").
+	Type string `json:"type"`
+	// Spawn position, in area pixel coordinates.
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	// Facing direction (e.g. "S", "NW"); south if empty.
+	Facing string `json:"facing,omitempty"`
+	// Faction ("player" or "monster"); monster if empty.
+	Faction string `json:"faction,omitempty"`
+	// AI profile name (e.g. "monster", "guard"); or empty if not controlled by
+	// AI.
+	AI string `json:"ai,omitempty"`
+	// Specifies whether the unit is controlled by the player.
+	Player bool `json:"player,omitempty"`
+}
+
+// Prop is a static prop of a scene.
+type Prop struct {
+	// Prop name.
+	Name string `json:"name"`
+	// Path to prop image, relative to the assets directory.
+	Image string `json:"image"`
+	// Position of the ground contact point, in area pixel coordinates.
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	// Offset from the top-left corner of the prop image to its ground contact
+	// point.
+	OffsetX int `json:"offset_x,omitempty"`
+	OffsetY int `json:"offset_y,omitempty"`
+	// Radius in pixels blocking movement; or 0 if not blocking.
+	Radius float64 `json:"radius,omitempty"`
+}
+
+// Entry is a named entry point of a scene, at which units arriving from other
+// areas are placed.
+type Entry struct {
+	// Entry point name.
+	Name string `json:"name"`
+	// Position, in area pixel coordinates.
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	// Facing direction of arriving units (e.g. "S"); south if empty.
+	Facing string `json:"facing,omitempty"`
+}
+
+// Exit is an exit region of a scene, leading to an entry point of another area.
+type Exit struct {
+	// Exit name.
+	Name string `json:"name"`
+	// Exit region, in area pixel coordinates.
+	Rect Rect `json:"rect"`
+	// Destination area name.
+	Dest string `json:"dest"`
+	// Entry point name in destination area.
+	Entry string `json:"entry"`
+}
+
+// Rect is a rectangle in area pixel coordinates.
+type Rect struct {
+	X0 float64 `json:"x0"`
+	Y0 float64 `json:"y0"`
+	X1 float64 `json:"x1"`
+	Y1 float64 `json:"y1"`
+}
+
+// Contains reports whether the given position is within the rectangle.
+func (r Rect) Contains(x, y float64) bool {
+	return x >= r.X0 && x < r.X1 && y >= r.Y0 && y < r.Y1
+}
+
+// Path returns the path to the scene file of the given area.
+func Path(area string) string {
+	return assets.FullPath(fmt.Sprintf("%s_scene.json", area))
+}
+
+// Load loads the scene of the given JSON file.
+func Load(path string) (*Scene, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	s := &Scene{}
+	if err := json.Unmarshal(buf, s); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse scene %q", path)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid scene %q", path)
+	}
+	return s, nil
+}
+
+// LoadArea loads the scene of the given area; or returns false if the area has
+// no scene file.
+func LoadArea(area string) (*Scene, bool, error) {
+	path := Path(area)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	s, err := Load(path)
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	return s, true, nil
+}
+
+// Validate validates the scene.
+func (s *Scene) Validate() error {
+	if len(s.Area) == 0 {
+		return errors.New("missing area name")
+	}
+	if _, err := s.LayerFiles(); err != nil {
+		return errors.WithStack(err)
+	}
+	players := 0
+	for _, spawn := range s.Spawns {
+		if len(spawn.Type) == 0 {
+			return errors.Errorf("missing unit type of spawn at (%v, %v)", spawn.X, spawn.Y)
+		}
+		if _, err := ParseFacing(spawn.Facing); err != nil {
+			return errors.WithStack(err)
+		}
+		switch spawn.Faction {
+		case "", "player", "monster":
+		default:
+			return errors.Errorf("invalid faction %q of spawn at (%v, %v); expected player or monster", spawn.Faction, spawn.X, spawn.Y)
+		}
+		if len(spawn.AI) > 0 {
+			if _, err := ai.Profile(spawn.AI); err != nil {
+				return errors.Wrapf(err, "invalid AI profile of spawn at (%v, %v)", spawn.X, spawn.Y)
+			}
+		}
+		if spawn.Player {
+			players++
+		}
+	}
+	if players > 1 {
+		return errors.Errorf("invalid number of player spawns; expected at most 1, got %d", players)
+	}
+	for _, prop := range s.Props {
+		if len(prop.Image) == 0 {
+			return errors.Errorf("missing image of prop %q", prop.Name)
+		}
+	}
+	for _, entry := range s.Entries {
+		if _, err := ParseFacing(entry.Facing); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	for _, exit := range s.Exits {
+		if len(exit.Dest) == 0 {
+			return errors.Errorf("missing destination area of exit %q", exit.Name)
+		}
+		if len(exit.Entry) == 0 {
+			return errors.Errorf("missing entry point of exit %q", exit.Name)
+		}
+	}
+	return nil
+}
+
+// LayerFiles returns the layer file names of the scene, indexed by layer kind.
+func (s *Scene) LayerFiles() (map[assets.LayerKind]string, error) {
+	if len(s.Layers) == 0 {
+		return nil, nil
+	}
+	files := make(map[assets.LayerKind]string)
+	for name, fileName := range s.Layers {
+		kind, err := assets.ParseLayerKind(name)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		files[kind] = fileName
+	}
+	return files, nil
+}
+
+// EntryByName returns the entry point of the given name. The boolean return
+// value indicates if the entry point was present in the scene.
+func (s *Scene) EntryByName(name string) (Entry, bool) {
+	for _, entry := range s.Entries {
+		if entry.Name == name {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// ParseFacing returns the facing direction of the given direction name; or
+// south if empty.
+func ParseFacing(s string) (facing.Dir, error) {
+	if len(s) == 0 {
+		return facing.South, nil
+	}
+	dir, err := facing.Parse(s)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return dir, nil
+}
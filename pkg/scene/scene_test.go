@@ -0,0 +1,31 @@
+package scene
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	golden := []struct {
+		name    string
+		s       Scene
+		wantErr bool
+	}{
+		{name: "valid", s: Scene{Area: "yenwood", Exits: []Exit{{Name: "to_town", Dest: "town", Entry: "east"}}}},
+		{name: "missing area", s: Scene{}, wantErr: true},
+		{name: "missing spawn type", s: Scene{Area: "yenwood", Spawns: []Spawn{{X: 1, Y: 2}}}, wantErr: true},
+		{name: "invalid faction", s: Scene{Area: "yenwood", Spawns: []Spawn{{Type: "balrog", Faction: "elves"}}}, wantErr: true},
+		{name: "valid spawns", s: Scene{Area: "yenwood", Spawns: []Spawn{{Type: "balrog", Faction: "player", Player: true}, {Type: "balrog", AI: "guard"}, {Type: "balrog"}}}},
+		{name: "invalid AI profile", s: Scene{Area: "yenwood", Spawns: []Spawn{{Type: "balrog", AI: "berserker"}}}, wantErr: true},
+		{name: "multiple player spawns", s: Scene{Area: "yenwood", Spawns: []Spawn{{Type: "balrog", Faction: "player", Player: true}, {Type: "balrog", Faction: "player", Player: true}}}, wantErr: true},
+		{name: "invalid facing", s: Scene{Area: "yenwood", Entries: []Entry{{Name: "west", Facing: "X"}}}, wantErr: true},
+		{name: "missing exit destination", s: Scene{Area: "yenwood", Exits: []Exit{{Name: "to_town", Entry: "east"}}}, wantErr: true},
+		{name: "missing exit entry", s: Scene{Area: "yenwood", Exits: []Exit{{Name: "to_town", Dest: "town"}}}, wantErr: true},
+	}
+	for _, g := range golden {
+		err := g.s.Validate()
+		if g.wantErr && err == nil {
+			t.Errorf("%s: expected error, got nil", g.name)
+		}
+		if !g.wantErr && err != nil {
+			t.Errorf("%s: unexpected error; %v", g.name, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package selection
+
+import (
+	"image"
+	"math"
+	"sort"
+
+	"github.com/mewspring/ren/pkg/entity"
+)
+
+// alphaThreshold is the minimum alpha value of sprite pixels hit by picking.
+const alphaThreshold = 0x40
+
+// Pick returns the topmost unit whose current frame covers the given world
+// position with an opaque pixel. Sprite sheet images are indexed by sprite sheet
+// path. The boolean return value indicates if a unit was hit.
+func Pick(units []*entity.Unit, imgs map[string]image.Image, x, y float64) (*entity.Unit, bool) {
+	// Test units front to back; the reverse of draw order.
+	sorted := append([]*entity.Unit(nil), units...)
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.Y != b.Y {
+			return a.Y > b.Y
+		}
+		if a.X != b.X {
+			return a.X > b.X
+		}
+		return a.ID > b.ID
+	})
+	for _, unit := range sorted {
+		if Hit(unit, imgs[unit.Type.SheetPath], x, y) {
+			return unit, true
+		}
+	}
+	return nil, false
+}
+
+// Hit reports whether the current frame of the given unit covers the given
+// world position with an opaque pixel of the sprite sheet image.
+func Hit(unit *entity.Unit, sheetImg image.Image, x, y float64) bool {
+	if sheetImg == nil {
+		return false
+	}
+	fr, ok := unit.Frame()
+	if !ok {
+		return false
+	}
+	dx, dy := unit.DrawPos()
+	p := image.Pt(fr.Min.X+int(math.Floor(x-dx)), fr.Min.Y+int(math.Floor(y-dy)))
+	if !p.In(fr) {
+		return false
+	}
+	_, _, _, a := sheetImg.At(p.X, p.Y).RGBA()
+	return a>>8 >= alphaThreshold
+}
+
+// Selectable returns the units among the given units that may be selected by
+// the player (see entity.Unit.Selectable).
+func Selectable(units []*entity.Unit) []*entity.Unit {
+	var selectable []*entity.Unit
+	for _, unit := range units {
+		if unit.Selectable() {
+			selectable = append(selectable, unit)
+		}
+	}
+	return selectable
+}
+
+// InRect returns the IDs of the selectable units whose ground contact point is
+// within the given world rectangle.
+func InRect(units []*entity.Unit, x0, y0, x1, y1 float64) []int {
+	minX, maxX := math.Min(x0, x1), math.Max(x0, x1)
+	minY, maxY := math.Min(y0, y1), math.Max(y0, y1)
+	var ids []int
+	for _, unit := range Selectable(units) {
+		if unit.X >= minX && unit.X <= maxX && unit.Y >= minY && unit.Y <= maxY {
+			ids = append(ids, unit.ID)
+		}
+	}
+	return ids
+}
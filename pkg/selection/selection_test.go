@@ -0,0 +1,119 @@
+package selection_test
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+
+	"github.com/mewspring/ren/pkg/entity"
+	"github.com/mewspring/ren/pkg/selection"
+	"github.com/mewspring/ren/pkg/sim/simtest"
+)
+
+func TestInRect(t *testing.T) {
+	w := simtest.World(1000, 1000)
+	typ := simtest.UnitType("hero")
+	player := simtest.SpawnPlayer(w, typ, 100, 100)
+	ally := simtest.Spawn(w, typ, entity.FactionPlayer, 150, 120)
+	simtest.Spawn(w, typ, entity.FactionPlayer, 300, 300)
+	dead := simtest.Spawn(w, typ, entity.FactionPlayer, 120, 120)
+	dead.SetDead()
+	simtest.Spawn(w, simtest.UnitType("monster"), entity.FactionMonster, 130, 110)
+	// Drag rectangles select the living units of the player faction within,
+	// regardless of drag direction.
+	want := []int{player.ID, ally.ID}
+	if got := selection.InRect(w.Units.Units, 200, 200, 50, 50); !reflect.DeepEqual(got, want) {
+		t.Errorf("selection mismatch; expected %v, got %v", want, got)
+	}
+}
+
+func TestHit(t *testing.T) {
+	w := simtest.World(1000, 1000)
+	typ := simtest.UnitType("hero")
+	// The frame of the unit covers (96, 93)-(104, 101); its opaque columns
+	// (98, 93)-(102, 101).
+	unit := simtest.Spawn(w, typ, entity.FactionPlayer, 100, 100)
+	img := sheetImage()
+	golden := []struct {
+		name string
+		x, y float64
+		want bool
+	}{
+		{name: "opaque pixel", x: 99.5, y: 97, want: true},
+		{name: "opaque pixel at edge", x: 101.9, y: 93, want: true},
+		{name: "transparent pixel", x: 96.5, y: 97, want: false},
+		{name: "transparent pixel at edge", x: 103.5, y: 100.5, want: false},
+		{name: "outside of frame", x: 99.5, y: 102, want: false},
+	}
+	for _, g := range golden {
+		if got := selection.Hit(unit, img, g.x, g.y); got != g.want {
+			t.Errorf("%s: hit mismatch at (%v, %v); expected %v, got %v", g.name, g.x, g.y, g.want, got)
+		}
+	}
+	if selection.Hit(unit, nil, 99.5, 97) {
+		t.Errorf("expected no hit of unit without sprite sheet image")
+	}
+}
+
+func TestPick(t *testing.T) {
+	w := simtest.World(1000, 1000)
+	typ := simtest.UnitType("hero")
+	// The frames of the units overlap; the front unit covers (97, 96)-(105, 104)
+	// and its opaque columns (99, 96)-(103, 104).
+	back := simtest.Spawn(w, typ, entity.FactionPlayer, 100, 100)
+	front := simtest.Spawn(w, typ, entity.FactionPlayer, 101, 103)
+	imgs := map[string]image.Image{typ.SheetPath: sheetImage()}
+	golden := []struct {
+		name string
+		x, y float64
+		want *entity.Unit
+	}{
+		{name: "overlapping opaque pixels", x: 100.5, y: 97, want: front},
+		{name: "transparent pixel of front unit", x: 98.2, y: 97, want: back},
+		{name: "back unit only", x: 100.5, y: 94, want: back},
+		{name: "transparent pixels", x: 96.5, y: 97, want: nil},
+	}
+	for _, g := range golden {
+		got, ok := selection.Pick(w.Units.Units, imgs, g.x, g.y)
+		if ok != (g.want != nil) || got != g.want {
+			t.Errorf("%s: picked unit mismatch at (%v, %v); expected %v, got %v", g.name, g.x, g.y, unitID(g.want), unitID(got))
+		}
+	}
+	// Picking is independent of the order of units.
+	units := []*entity.Unit{front, back}
+	if got, _ := selection.Pick(units, imgs, 100.5, 97); got != front {
+		t.Errorf("picked unit mismatch; expected %d, got %d", front.ID, unitID(got))
+	}
+}
+
+// sheetImage returns a sprite sheet image of the unit type of simtest, with
+// the four center columns of each frame opaque and the others transparent.
+func sheetImage() *image.NRGBA {
+	const (
+		frameSize = 8
+		nframes   = 17
+		nrows     = 8
+	)
+	img := image.NewNRGBA(image.Rect(0, 0, nframes*frameSize, nrows*frameSize))
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if fx := x % frameSize; fx >= 2 && fx < 6 {
+				img.SetNRGBA(x, y, color.NRGBA{R: 0xFF, A: 0xFF})
+			} else {
+				// Nearly transparent; below the alpha threshold of picking.
+				img.SetNRGBA(x, y, color.NRGBA{R: 0xFF, A: 0x20})
+			}
+		}
+	}
+	return img
+}
+
+// unitID returns the ID of the given unit; or 0 if nil.
+func unitID(unit *entity.Unit) int {
+	if unit == nil {
+		return 0
+	}
+	return unit.ID
+}
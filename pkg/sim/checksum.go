@@ -0,0 +1,97 @@
+package sim
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"math"
+)
+
+// Checksum returns a checksum of the simulation state of the world; used to
+// detect desyncs between recorded and replayed simulations.
+//
+// The checksum covers the state advanced by the simulation (units, projectiles,
+// selection, random number generator and tick), but not static state such as the map area and lighting.
+func (w *World) Checksum() uint64 {
+	h := &hasher{h: fnv.New64a()}
+	h.int(w.Tick)
+	h.bool(w.Paused)
+	h.uint64(w.RandState())
+	h.int(w.PlayerID)
+	h.int(len(w.Selected))
+	for _, id := range w.Selected {
+		h.int(id)
+	}
+	h.int(len(w.Units.Units))
+	for _, unit := range w.Units.Units {
+		h.int(unit.ID)
+		h.float(unit.X)
+		h.float(unit.Y)
+		h.int(int(unit.Dir))
+		h.int(int(unit.Faction))
+		h.int(unit.HP)
+		h.int(unit.Target)
+		h.float(unit.AimX)
+		h.float(unit.AimY)
+		h.int(unit.Attacker)
+		h.int(len(unit.Path))
+		for _, p := range unit.Path {
+			h.float(p.X)
+			h.float(p.Y)
+		}
+		h.int(int(unit.Anim.State))
+		if a, ok := unit.Anim.Anim(); ok {
+			h.int(a.CurFrame)
+			h.int(a.Inc)
+			h.int(int(a.Elapsed))
+		}
+	}
+	// Projectile IDs are not covered, as the IDs of projectiles fired after
+	// restoring a saved game may differ.
+	h.int(len(w.Projectiles))
+	for _, p := range w.Projectiles {
+		h.int(p.OwnerID)
+		h.float(p.X)
+		h.float(p.Y)
+		h.float(p.DX)
+		h.float(p.DY)
+		h.float(p.Travelled)
+		h.bool(p.Done)
+	}
+	return h.h.Sum64()
+}
+
+// hasher hashes simulation state in a fixed binary encoding.
+type hasher struct {
+	// Underlying hash.
+	h hash.Hash64
+	// Encoding buffer.
+	buf [8]byte
+}
+
+// int hashes the given integer.
+func (h *hasher) int(v int) {
+	binary.LittleEndian.PutUint64(h.buf[:], uint64(v))
+	h.h.Write(h.buf[:])
+}
+
+// float hashes the given floating-point number by its bit representation.
+func (h *hasher) float(v float64) {
+	binary.LittleEndian.PutUint64(h.buf[:], math.Float64bits(v))
+	h.h.Write(h.buf[:])
+}
+
+// bool hashes the given boolean.
+func (h *hasher) bool(v bool) {
+	if v {
+		h.int(1)
+	} else {
+		h.int(0)
+	}
+}
+
+// uint64 hashes the given unsigned integer.
+func (h *hasher) uint64(v uint64) {
+	binary.LittleEndian.PutUint64(h.buf[:], v)
+	h.h.Write(h.buf[:])
+}
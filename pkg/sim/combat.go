@@ -0,0 +1,107 @@
+package sim
+
+import (
+	"math"
+
+	"github.com/mewspring/ren/pkg/anim"
+	"github.com/mewspring/ren/pkg/entity"
+	"github.com/mewspring/ren/pkg/facing"
+)
+
+// repathTicks specifies the number of simulation ticks between path updates of
+// units chasing a moving target.
+const repathTicks = TPS / 4
+
+// AttackGroup orders the given units to attack the target unit. Units not
+// hostile to the target ignore the order.
+func (w *World) AttackGroup(units []*entity.Unit, target *entity.Unit) {
+	for _, unit := range units {
+		if unit.Dead() || !unit.Hostile(target) {
+			continue
+		}
+		unit.Target = target.ID
+		unit.Path = nil
+	}
+}
+
+// updateCombat advances the attack of the given unit on its target by one tick;
+// the unit chases its target along a path until within attack range, and then
+// swings at it. The attack ends when the target dies.
+func (w *World) updateCombat(unit *entity.Unit) {
+	if unit.Target == 0 || unit.Dead() {
+		return
+	}
+	target, ok := w.Units.ByID(unit.Target)
+	if !ok || target.Dead() {
+		unit.Target = 0
+		unit.Path = nil
+		unit.Idle()
+		return
+	}
+	if inRange(unit, target) {
+		unit.Path = nil
+		unit.Idle()
+		unit.Attack(target.X, target.Y)
+		return
+	}
+	if len(unit.Path) == 0 || w.Tick%repathTicks == 0 {
+		w.MoveTo(unit, target.X, target.Y)
+	}
+}
+
+// resolveHits applies the damage of the units whose swing connected during the
+// last tick, and fires the projectiles of the units whose ranged attack was
+// released.
+func (w *World) resolveHits() {
+	for _, unit := range w.Units.Units {
+		if unit.Dead() {
+			continue
+		}
+		if unit.Anim.Activated(anim.StateShoot) && unit.Type.Projectile != nil {
+			w.Fire(unit.Type.Projectile, unit, unit.AimX, unit.AimY)
+		}
+		if !unit.Anim.Activated(anim.StateSwing) {
+			continue
+		}
+		if target, ok := w.hitTarget(unit); ok {
+			target.Attacker = unit.ID
+			target.TakeDamage(unit.Stats.Damage)
+		}
+	}
+}
+
+// hitTarget returns the unit hit by the swing of the given unit; its attack
+// target if within range, or otherwise the nearest living hostile unit within
+// range in the facing direction of the unit. The boolean return value indicates if a unit
+// was hit.
+func (w *World) hitTarget(unit *entity.Unit) (*entity.Unit, bool) {
+	if target, ok := w.Units.ByID(unit.Target); ok && !target.Dead() && unit.Hostile(target) && inRange(unit, target) {
+		return target, true
+	}
+	var hit *entity.Unit
+	minDist := math.Inf(+1)
+	for _, other := range w.Units.Units {
+		if other.Dead() || !unit.Hostile(other) || !inRange(unit, other) {
+			continue
+		}
+		if dir, ok := facing.FromScreen(other.X-unit.X, other.Y-unit.Y); ok && dir != unit.Dir {
+			continue
+		}
+		if dist := distance(unit, other); dist < minDist {
+			hit, minDist = other, dist
+		}
+	}
+	return hit, hit != nil
+}
+
+// inRange reports whether the target unit is within attack range of the given
+// unit.
+func inRange(unit, target *entity.Unit) bool {
+	return distance(unit, target) <= unit.Stats.Range
+}
+
+// distance returns the distance between the ground contact points of the given
+// units.
+func distance(a, b *entity.Unit) float64 {
+	return math.Hypot(b.X-a.X, b.Y-a.Y)
+}
@@ -0,0 +1,54 @@
+package sim_test
+
+import (
+	"testing"
+
+	"github.com/mewspring/ren/pkg/entity"
+	"github.com/mewspring/ren/pkg/sim"
+	"github.com/mewspring/ren/pkg/sim/simtest"
+)
+
+func TestSwingHitsHostile(t *testing.T) {
+	w := simtest.World(1000, 1000)
+	typ := simtest.UnitType("hero")
+	player := simtest.SpawnPlayer(w, typ, 500, 500)
+	// The ally is nearer than the monster, in the same direction.
+	ally := simtest.Spawn(w, typ, entity.FactionPlayer, 520, 500)
+	monster := simtest.Spawn(w, simtest.UnitType("monster"), entity.FactionMonster, 540, 500)
+	w.Update(sim.Input{Attack: true, TargetX: 540, TargetY: 500})
+	simtest.Run(w, sim.Input{}, sim.TPS)
+	if ally.HP != simtest.MaxHP {
+		t.Errorf("ally HP mismatch; expected %d, got %d", simtest.MaxHP, ally.HP)
+	}
+	if want := simtest.MaxHP - simtest.Damage; monster.HP != want {
+		t.Errorf("monster HP mismatch; expected %d, got %d", want, monster.HP)
+	}
+	if player.HP != simtest.MaxHP {
+		t.Errorf("player HP mismatch; expected %d, got %d", simtest.MaxHP, player.HP)
+	}
+}
+
+func TestAttackGroup(t *testing.T) {
+	w := simtest.World(1000, 1000)
+	typ := simtest.UnitType("hero")
+	player := simtest.SpawnPlayer(w, typ, 500, 500)
+	ally := simtest.Spawn(w, typ, entity.FactionPlayer, 600, 500)
+	monster := simtest.Spawn(w, simtest.UnitType("monster"), entity.FactionMonster, 700, 500)
+	// Orders to attack units of the own faction are ignored.
+	w.AttackGroup([]*entity.Unit{player}, ally)
+	if player.Target != 0 {
+		t.Errorf("expected no attack on ally, got target %d", player.Target)
+	}
+	w.AttackGroup([]*entity.Unit{player, ally}, monster)
+	if player.Target != monster.ID || ally.Target != monster.ID {
+		t.Errorf("target mismatch; expected %d, got %d and %d", monster.ID, player.Target, ally.Target)
+	}
+	// The attackers chase and kill the monster.
+	simtest.Run(w, sim.Input{}, 10*sim.TPS)
+	if !monster.Dead() {
+		t.Errorf("expected monster killed, got HP %d", monster.HP)
+	}
+	if player.HP != simtest.MaxHP || ally.HP != simtest.MaxHP {
+		t.Errorf("expected attackers unharmed, got HP %d and %d", player.HP, ally.HP)
+	}
+}
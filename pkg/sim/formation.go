@@ -0,0 +1,36 @@
+package sim
+
+import (
+	"math"
+
+	"github.com/mewspring/ren/pkg/pathfind"
+)
+
+// formationSpacing is the distance in pixels between adjacent units of a
+// formation.
+const formationSpacing = 64
+
+// formation returns the offsets from the destination of n units moving in
+// formation; a square grid centered on the destination, filled row by row.
+func formation(n int) []pathfind.Point {
+	ncols := int(math.Ceil(math.Sqrt(float64(n))))
+	if ncols == 0 {
+		return nil
+	}
+	nrows := (n + ncols - 1) / ncols
+	offs := make([]pathfind.Point, 0, n)
+	for i := 0; i < n; i++ {
+		col, row := i%ncols, i/ncols
+		// Center the last (possibly partial) row.
+		rowLen := ncols
+		if row == nrows-1 {
+			rowLen = n - row*ncols
+		}
+		off := pathfind.Point{
+			X: (float64(col) - float64(rowLen-1)/2) * formationSpacing,
+			Y: (float64(row) - float64(nrows-1)/2) * formationSpacing,
+		}
+		offs = append(offs, off)
+	}
+	return offs
+}
@@ -0,0 +1,129 @@
+package sim
+
+import (
+	"math"
+
+	"github.com/mewspring/ren/pkg/entity"
+)
+
+// Fire fires a projectile of the given type from the given unit towards the
+// target position.
+func (w *World) Fire(typ *entity.ProjectileType, owner *entity.Unit, x, y float64) *entity.Projectile {
+	p := entity.NewProjectile(typ, owner, x, y)
+	w.nextProjectileID++
+	p.ID = w.nextProjectileID
+	w.Projectiles = append(w.Projectiles, p)
+	return p
+}
+
+// InsertProjectile inserts the given projectile into the world, keeping its ID
+// (e.g. when restoring a saved game).
+func (w *World) InsertProjectile(p *entity.Projectile) {
+	if p.ID > w.nextProjectileID {
+		w.nextProjectileID = p.ID
+	}
+	w.Projectiles = append(w.Projectiles, p)
+}
+
+// updateProjectiles moves the projectiles in flight by one tick, applying their
+// effects on impact with hostile units or non-walkable terrain, and removes
+// projectiles which have hit or expired.
+func (w *World) updateProjectiles() {
+	for _, p := range w.Projectiles {
+		w.updateProjectile(p)
+	}
+	live := w.Projectiles[:0]
+	for _, p := range w.Projectiles {
+		if !p.Done {
+			live = append(live, p)
+		}
+	}
+	for i := len(live); i < len(w.Projectiles); i++ {
+		w.Projectiles[i] = nil
+	}
+	w.Projectiles = live
+}
+
+// updateProjectile moves the given projectile by one tick.
+func (w *World) updateProjectile(p *entity.Projectile) {
+	if p.Done {
+		return
+	}
+	if p.Anim.NFrames > 0 {
+		p.Anim.Update(TickDur)
+	}
+	step := p.Type.Speed * TickDur.Seconds()
+	if rem := p.Type.Range - p.Travelled; step > rem {
+		step = rem
+	}
+	x0, y0 := p.X, p.Y
+	x1, y1 := x0+p.DX*step, y0+p.DY*step
+	if hit, ok := w.projectileHit(p, x0, y0, x1, y1); ok {
+		p.X, p.Y = hit.X, hit.Y
+		w.impact(p, hit)
+		return
+	}
+	if w.Nav != nil && w.Nav.SegmentBlocked(x0, y0, x1, y1) {
+		w.impact(p, nil)
+		return
+	}
+	p.X, p.Y = x1, y1
+	p.Travelled += step
+	if p.Travelled >= p.Type.Range {
+		// Expired without impact.
+		p.Done = true
+	}
+}
+
+// projectileHit returns the first living hostile unit within collision radius
+// of the flight segment of the given projectile from (x0, y0) to (x1, y1). The
+// boolean return value indicates if a unit was hit.
+func (w *World) projectileHit(p *entity.Projectile, x0, y0, x1, y1 float64) (*entity.Unit, bool) {
+	var hit *entity.Unit
+	minT := math.Inf(+1)
+	for _, unit := range w.Units.Units {
+		if unit.Dead() || unit.ID == p.OwnerID || unit.Faction == p.Faction {
+			continue
+		}
+		t, dist := closestPoint(x0, y0, x1, y1, unit.X, unit.Y)
+		if dist > p.Type.Radius || t >= minT {
+			continue
+		}
+		hit, minT = unit, t
+	}
+	return hit, hit != nil
+}
+
+// impact applies the effects of the given projectile on impact; damaging the
+// unit hit (or nil if none) and any hostile units within splash radius.
+func (w *World) impact(p *entity.Projectile, hit *entity.Unit) {
+	p.Done = true
+	if hit != nil {
+		hit.Attacker = p.OwnerID
+		hit.TakeDamage(p.Type.Damage)
+	}
+	if p.Type.Splash <= 0 {
+		return
+	}
+	for _, unit := range w.Units.Units {
+		if unit == hit || unit.Faction == p.Faction {
+			continue
+		}
+		if math.Hypot(unit.X-p.X, unit.Y-p.Y) <= p.Type.Splash {
+			unit.Attacker = p.OwnerID
+			unit.TakeDamage(p.Type.Damage)
+		}
+	}
+}
+
+// closestPoint returns the position along the segment from (x0, y0) to (x1, y1)
+// closest to the point (x, y), as a fraction t of the segment length, and its
+// distance to the point.
+func closestPoint(x0, y0, x1, y1, x, y float64) (t, dist float64) {
+	dx, dy := x1-x0, y1-y0
+	if l2 := dx*dx + dy*dy; l2 > 0 {
+		t = ((x-x0)*dx + (y-y0)*dy) / l2
+		t = math.Max(0, math.Min(1, t))
+	}
+	return t, math.Hypot(x0+dx*t-x, y0+dy*t-y)
+}
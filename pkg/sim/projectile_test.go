@@ -0,0 +1,152 @@
+package sim_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mewspring/ren/pkg/anim"
+	"github.com/mewspring/ren/pkg/entity"
+	"github.com/mewspring/ren/pkg/nav"
+	"github.com/mewspring/ren/pkg/sim"
+	"github.com/mewspring/ren/pkg/sim/simtest"
+)
+
+// flightTicks is the number of ticks for projectiles to cover their range.
+const flightTicks = simtest.ProjectileRange * sim.TPS / simtest.ProjectileSpeed
+
+func TestProjectileHit(t *testing.T) {
+	w := simtest.World(1000, 1000)
+	typ := simtest.RangedUnitType("mage", simtest.ProjectileType("bolt", false))
+	shooter := simtest.SpawnPlayer(w, typ, 100, 500)
+	// The ally is in the line of fire, nearer than the monster.
+	ally := simtest.Spawn(w, simtest.UnitType("hero"), entity.FactionPlayer, 150, 500)
+	monster := simtest.Spawn(w, simtest.UnitType("monster"), entity.FactionMonster, 250, 500)
+	p := w.Fire(typ.Projectile, shooter, monster.X, monster.Y)
+	simtest.Run(w, sim.Input{}, flightTicks)
+	if !p.Done || len(w.Projectiles) != 0 {
+		t.Errorf("expected projectile removed on impact")
+	}
+	// Projectiles pass through their shooter and units of its faction.
+	if shooter.HP != simtest.MaxHP {
+		t.Errorf("shooter HP mismatch; expected %d, got %d", simtest.MaxHP, shooter.HP)
+	}
+	if ally.HP != simtest.MaxHP {
+		t.Errorf("ally HP mismatch; expected %d, got %d", simtest.MaxHP, ally.HP)
+	}
+	if want := simtest.MaxHP - simtest.ProjectileDamage; monster.HP != want {
+		t.Errorf("monster HP mismatch; expected %d, got %d", want, monster.HP)
+	}
+	if monster.Attacker != shooter.ID {
+		t.Errorf("attacker mismatch; expected %d, got %d", shooter.ID, monster.Attacker)
+	}
+}
+
+func TestProjectileSplash(t *testing.T) {
+	w := simtest.World(1000, 1000)
+	typ := simtest.RangedUnitType("mage", simtest.ProjectileType("fireball", true))
+	shooter := simtest.SpawnPlayer(w, typ, 100, 500)
+	monsterType := simtest.UnitType("monster")
+	target := simtest.Spawn(w, monsterType, entity.FactionMonster, 250, 500)
+	near := simtest.Spawn(w, monsterType, entity.FactionMonster, 250, 520)
+	far := simtest.Spawn(w, monsterType, entity.FactionMonster, 250, 540)
+	ally := simtest.Spawn(w, simtest.UnitType("hero"), entity.FactionPlayer, 250, 480)
+	w.Fire(typ.Projectile, shooter, target.X, target.Y)
+	simtest.Run(w, sim.Input{}, flightTicks)
+	// Hostile units within splash radius of the impact are damaged once.
+	want := simtest.MaxHP - simtest.ProjectileDamage
+	golden := []struct {
+		name string
+		unit *entity.Unit
+		want int
+	}{
+		{name: "target", unit: target, want: want},
+		{name: "monster within splash radius", unit: near, want: want},
+		{name: "monster outside of splash radius", unit: far, want: simtest.MaxHP},
+		{name: "ally within splash radius", unit: ally, want: simtest.MaxHP},
+	}
+	for _, g := range golden {
+		if g.unit.HP != g.want {
+			t.Errorf("%s: HP mismatch; expected %d, got %d", g.name, g.want, g.unit.HP)
+		}
+	}
+}
+
+func TestProjectileRange(t *testing.T) {
+	w := simtest.World(1000, 1000)
+	typ := simtest.RangedUnitType("mage", simtest.ProjectileType("bolt", false))
+	shooter := simtest.SpawnPlayer(w, typ, 100, 500)
+	// The monster is just out of range.
+	x := 100 + simtest.ProjectileRange + simtest.ProjectileRadius + 1.0
+	monster := simtest.Spawn(w, simtest.UnitType("monster"), entity.FactionMonster, x, 500)
+	p := w.Fire(typ.Projectile, shooter, monster.X, monster.Y)
+	simtest.Run(w, sim.Input{}, flightTicks-1)
+	if p.Done {
+		t.Fatalf("expected projectile in flight before covering its range, travelled %v", p.Travelled)
+	}
+	simtest.Run(w, sim.Input{}, 2)
+	if !p.Done || len(w.Projectiles) != 0 {
+		t.Errorf("expected projectile expired at range")
+	}
+	if math.Abs(p.Travelled-simtest.ProjectileRange) > 1e-9 || math.Abs(p.X-(100+simtest.ProjectileRange)) > 1e-9 {
+		t.Errorf("flight mismatch; expected %v pixels to x = %v, got %v pixels to x = %v", simtest.ProjectileRange, 100+simtest.ProjectileRange, p.Travelled, p.X)
+	}
+	if monster.HP != simtest.MaxHP {
+		t.Errorf("monster HP mismatch; expected %d, got %d", simtest.MaxHP, monster.HP)
+	}
+}
+
+func TestProjectileBlocked(t *testing.T) {
+	// A wall of non-walkable cells at x = 160 to 180 stands between the shooter
+	// and the monster.
+	w := simtest.World(1000, 1000)
+	w.Nav = nav.NewGrid(w.Area.BackgroundLayer.Bounds(), 20)
+	for row := 0; row < w.Nav.NRows; row++ {
+		w.Nav.Walkable[row*w.Nav.NCols+8] = false
+	}
+	typ := simtest.RangedUnitType("mage", simtest.ProjectileType("fireball", true))
+	shooter := simtest.SpawnPlayer(w, typ, 100, 500)
+	monster := simtest.Spawn(w, simtest.UnitType("monster"), entity.FactionMonster, 200, 500)
+	p := w.Fire(typ.Projectile, shooter, monster.X, monster.Y)
+	simtest.Run(w, sim.Input{}, flightTicks)
+	if !p.Done || len(w.Projectiles) != 0 {
+		t.Errorf("expected projectile removed on impact with wall")
+	}
+	if p.X >= 160 {
+		t.Errorf("expected projectile stopped before wall at x = 160, got x = %v", p.X)
+	}
+	if monster.HP != simtest.MaxHP {
+		t.Errorf("monster HP mismatch; expected %d, got %d", simtest.MaxHP, monster.HP)
+	}
+}
+
+func TestShootRelease(t *testing.T) {
+	// Projectiles are fired once, on the release frame of the shoot animation.
+	w := simtest.World(1000, 1000)
+	typ := simtest.RangedUnitType("mage", simtest.ProjectileType("bolt", false))
+	shooter := simtest.SpawnPlayer(w, typ, 100, 500)
+	w.Update(sim.Input{Shoot: true, TargetX: 300, TargetY: 500})
+	if shooter.Anim.State != anim.StateShoot {
+		t.Fatalf("animation state mismatch; expected %v, got %v", anim.StateShoot, shooter.Anim.State)
+	}
+	def := typ.Sheet.Defs[anim.StateShoot]
+	fired := 0
+	for i := 0; i < sim.TPS && shooter.Anim.State == anim.StateShoot; i++ {
+		a, _ := shooter.Anim.Anim()
+		before := a.CurFrame
+		w.Update(sim.Input{})
+		n := len(w.Projectiles)
+		if n > fired {
+			if after, _ := shooter.Anim.FrameNum(); before >= def.ActiveFrame || after < def.ActiveFrame {
+				t.Errorf("projectile fired on frame transition %d to %d; expected release frame %d", before, after, def.ActiveFrame)
+			}
+		}
+		fired = n
+	}
+	if fired != 1 {
+		t.Fatalf("projectile count mismatch; expected 1, got %d", fired)
+	}
+	p := w.Projectiles[0]
+	if p.OwnerID != shooter.ID || p.DX != 1 || p.DY != 0 {
+		t.Errorf("projectile mismatch; expected owner %d heading (1, 0), got owner %d heading (%v, %v)", shooter.ID, p.OwnerID, p.DX, p.DY)
+	}
+}
@@ -0,0 +1,28 @@
+package sim
+
+// Source is a source of uniformly distributed pseudo-random numbers, whose
+// state may be saved and restored (e.g. by save games); unlike the sources of
+// math/rand. It implements rand.Source64, using the SplitMix64 generator.
+type Source struct {
+	// Generator state.
+	State uint64
+}
+
+// Seed initializes the generator state from the given seed.
+func (src *Source) Seed(seed int64) {
+	src.State = uint64(seed)
+}
+
+// Uint64 returns a pseudo-random 64-bit value.
+func (src *Source) Uint64() uint64 {
+	src.State += 0x9E3779B97F4A7C15
+	z := src.State
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return z ^ (z >> 31)
+}
+
+// Int63 returns a non-negative pseudo-random 63-bit integer.
+func (src *Source) Int63() int64 {
+	return int64(src.Uint64() >> 1)
+}
@@ -0,0 +1,336 @@
+package sim
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/mewspring/ren/pkg/anim"
+	"github.com/mewspring/ren/pkg/assets"
+	"github.com/mewspring/ren/pkg/entity"
+	"github.com/mewspring/ren/pkg/light"
+	"github.com/mewspring/ren/pkg/nav"
+	"github.com/mewspring/ren/pkg/pathfind"
+)
+
+// TPS is the number of simulation ticks per second.
+const TPS = 60
+
+// TickDur is the duration of a simulation tick.
+const TickDur = time.Second / TPS
+
+// World is the simulated game world. It is advanced in fixed time steps,
+// independently of rendering and frame rate.
+type World struct {
+	// Current map area.
+	Area *assets.Area
+	// Units of the world.
+	Units *entity.Collection
+	// Projectiles in flight.
+	Projectiles []*entity.Projectile
+	// Static props of the world.
+	Props []*entity.Prop
+	// Lighting of the world; or nil if unlit.
+	Lighting *light.Scene
+	// Walkability grid of the world; or nil if all positions are walkable.
+	Nav *nav.Grid
+	// Controllers of non-player units (e.g. AI), updated once per tick.
+	Controllers []Controller
+	// Random number generator of the simulation; the only source of randomness
+	// of the world, to keep simulations reproducible.
+	Rand *rand.Rand
+	// Seed of the random number generator.
+	Seed int64
+	// Source of the random number generator.
+	src *Source
+	// ID of unit controlled by the player; or 0 if none.
+	PlayerID int
+	// IDs of selected units, in order of selection.
+	Selected []int
+	// Number of simulation ticks since start of simulation.
+	Tick int
+	// Specifies whether the simulation is paused. Orders and selection changes
+	// are accepted while paused, and carried out once resumed.
+	Paused bool
+	// ID of the next projectile fired.
+	nextProjectileID int
+}
+
+// NewWorld returns a new world of the given map area, without units.
+func NewWorld(area *assets.Area) *World {
+	src := &Source{}
+	w := &World{
+		Area:  area,
+		Units: entity.NewCollection(),
+		Rand:  rand.New(src),
+		src:   src,
+	}
+	w.Reseed(1)
+	return w
+}
+
+// Reseed resets the random number generator of the world to the given seed.
+func (w *World) Reseed(seed int64) {
+	w.Rand.Seed(seed)
+	w.Seed = seed
+}
+
+// RandState returns the state of the random number generator of the world
+// (e.g. to save the game).
+func (w *World) RandState() uint64 {
+	return w.src.State
+}
+
+// SetRandState restores the state of the random number generator of the world
+// (e.g. when loading a saved game), to continue the sequence of random numbers
+// from where it was saved.
+func (w *World) SetRandState(state uint64) {
+	w.src.State = state
+}
+
+// Controller controls units of the world (e.g. AI).
+type Controller interface {
+	// Update updates the controlled units by one simulation tick.
+	Update(w *World)
+}
+
+// Input holds the player input of a simulation tick.
+type Input struct {
+	// Movement direction of the player unit in screen coordinates (e.g. -1, 0,
+	// +1 per axis); or zero if not moving.
+	MoveX float64 `json:"move_x,omitempty"`
+	MoveY float64 `json:"move_y,omitempty"`
+	// Specifies whether the player unit attacks.
+	Attack bool `json:"attack,omitempty"`
+	// Specifies whether the player unit fires a ranged attack.
+	Shoot bool `json:"shoot,omitempty"`
+	// Attack target position, in area pixel coordinates.
+	TargetX float64 `json:"target_x,omitempty"`
+	TargetY float64 `json:"target_y,omitempty"`
+	// Specifies whether the player unit is ordered to move to a destination.
+	MoveTo bool `json:"move_to,omitempty"`
+	// Destination of move order, in area pixel coordinates. Move orders apply
+	// to the selected units; or the player unit if no unit is selected.
+	DestX float64 `json:"dest_x,omitempty"`
+	DestY float64 `json:"dest_y,omitempty"`
+	// ID of unit to attack by the selected units; or 0 if none. Attack orders
+	// apply to the selected units; or the player unit if no unit is selected.
+	AttackID int `json:"attack_id,omitempty"`
+	// Selection change; or nil if the selection is unchanged.
+	Select *Select `json:"select,omitempty"`
+}
+
+// Select is a selection change.
+type Select struct {
+	// IDs of units to select.
+	IDs []int `json:"ids"`
+	// Specifies whether to add the units to the current selection, instead of
+	// replacing it.
+	Add bool `json:"add,omitempty"`
+}
+
+// Player returns the unit controlled by the player. The boolean return value
+// indicates if such a unit exists.
+func (w *World) Player() (*entity.Unit, bool) {
+	return w.Units.ByID(w.PlayerID)
+}
+
+// Update advances the simulation by one tick, based on the given player input.
+// While paused, only selection changes and orders are applied.
+func (w *World) Update(in Input) {
+	if in.Select != nil {
+		w.selectUnits(in.Select)
+	}
+	player, ok := w.Player()
+	if ok {
+		w.handleOrders(player, in)
+	}
+	if w.Paused {
+		return
+	}
+	if ok && !player.Dead() {
+		w.handleInput(player, in)
+	}
+	for _, c := range w.Controllers {
+		c.Update(w)
+	}
+	for _, unit := range w.Units.Units {
+		w.updateCombat(unit)
+		w.followPath(unit)
+	}
+	w.Units.Update(TickDur)
+	w.resolveHits()
+	w.updateProjectiles()
+	w.Tick++
+}
+
+// handleOrders orders the selected units (or the given player unit if no unit
+// is selected) to move or attack, based on user input.
+func (w *World) handleOrders(player *entity.Unit, in Input) {
+	units := w.SelectedUnits()
+	if len(units) == 0 {
+		if !player.Selectable() {
+			return
+		}
+		units = []*entity.Unit{player}
+	}
+	switch {
+	case in.AttackID != 0:
+		if target, ok := w.Units.ByID(in.AttackID); ok && !target.Dead() {
+			w.AttackGroup(units, target)
+		}
+	case in.MoveTo:
+		for _, unit := range units {
+			unit.Target = 0
+		}
+		w.MoveGroup(units, in.DestX, in.DestY)
+	}
+}
+
+// handleInput moves the given player unit based on user input.
+func (w *World) handleInput(unit *entity.Unit, in Input) {
+	switch {
+	case in.Attack:
+		unit.Path = nil
+		unit.Target = 0
+		unit.Attack(in.TargetX, in.TargetY)
+	case in.Shoot:
+		unit.Path = nil
+		unit.Target = 0
+		unit.Shoot(in.TargetX, in.TargetY)
+	case in.MoveX != 0 || in.MoveY != 0:
+		unit.Path = nil
+		unit.Target = 0
+		// Distance moved per tick.
+		step := unit.Stats.Speed * TickDur.Seconds()
+		n := math.Hypot(in.MoveX, in.MoveY)
+		w.move(unit, in.MoveX/n*step, in.MoveY/n*step)
+	case len(unit.Path) == 0 && unit.Target == 0:
+		unit.Idle()
+	}
+}
+
+// selectUnits changes the selection of units. Only living units of the player
+// faction are selected.
+func (w *World) selectUnits(sel *Select) {
+	if !sel.Add {
+		w.Selected = nil
+	}
+	for _, id := range sel.IDs {
+		unit, ok := w.Units.ByID(id)
+		if !ok || !unit.Selectable() || w.IsSelected(id) {
+			continue
+		}
+		w.Selected = append(w.Selected, id)
+	}
+}
+
+// IsSelected reports whether the unit with the given ID is selected.
+func (w *World) IsSelected(id int) bool {
+	for _, selected := range w.Selected {
+		if selected == id {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectedUnits returns the selected units present in the world that are still
+// selectable (e.g. excluding units killed while selected).
+func (w *World) SelectedUnits() []*entity.Unit {
+	var units []*entity.Unit
+	for _, id := range w.Selected {
+		if unit, ok := w.Units.ByID(id); ok && unit.Selectable() {
+			units = append(units, unit)
+		}
+	}
+	return units
+}
+
+// MoveGroup orders the given units to move to the given destination, in a
+// formation centered on the destination.
+func (w *World) MoveGroup(units []*entity.Unit, x, y float64) {
+	for i, off := range formation(len(units)) {
+		w.MoveTo(units[i], x+off.X, y+off.Y)
+	}
+}
+
+// Place places the given units at the given position in formation, without
+// moving them along paths (e.g. the party arriving at the entry point of an
+// area).
+func (w *World) Place(units []*entity.Unit, x, y float64) {
+	for i, off := range formation(len(units)) {
+		unit := units[i]
+		unit.X, unit.Y = x+off.X, y+off.Y
+		unit.Path = nil
+		unit.Target = 0
+	}
+}
+
+// MoveTo orders the given unit to move to the given destination, along a path
+// avoiding obstacles of the walkability grid. The boolean return value
+// indicates if a path to the destination (or its nearest walkable position) was
+// found; if not, the unit stops moving, rather than following its previous
+// path.
+func (w *World) MoveTo(unit *entity.Unit, x, y float64) bool {
+	dest := pathfind.Point{X: x, Y: y}
+	if w.Nav == nil {
+		unit.Path = []pathfind.Point{dest}
+		return true
+	}
+	start := pathfind.Point{X: unit.X, Y: unit.Y}
+	path, ok := pathfind.Find(w.Nav, start, dest)
+	if !ok {
+		unit.Path = nil
+		return false
+	}
+	unit.Path = path
+	return true
+}
+
+// followPath moves the given unit along its path by one tick, facing along the
+// path and returning to stance on arrival.
+func (w *World) followPath(unit *entity.Unit) {
+	if len(unit.Path) == 0 {
+		return
+	}
+	// Distance left to move this tick.
+	step := unit.Stats.Speed * TickDur.Seconds()
+	for step > 0 && len(unit.Path) > 0 {
+		next := unit.Path[0]
+		dx, dy := next.X-unit.X, next.Y-unit.Y
+		dist := math.Hypot(dx, dy)
+		if dist > step {
+			dx, dy = dx/dist*step, dy/dist*step
+			dist = step
+		} else {
+			unit.Path = unit.Path[1:]
+		}
+		if dist > 0 {
+			unit.Move(dx, dy)
+			if unit.Anim.State != anim.StateRun {
+				// Unable to move (e.g. dead).
+				unit.Path = nil
+				return
+			}
+		}
+		step -= dist
+	}
+	if len(unit.Path) == 0 {
+		unit.Path = nil
+		unit.Idle()
+	}
+}
+
+// move moves the given unit by (dx, dy), sliding along obstacles of the
+// walkability grid.
+func (w *World) move(unit *entity.Unit, dx, dy float64) {
+	if w.Nav != nil {
+		dx, dy = w.Nav.Slide(unit.X, unit.Y, dx, dy)
+	}
+	if dx == 0 && dy == 0 {
+		unit.Idle()
+		return
+	}
+	unit.Move(dx, dy)
+}
@@ -0,0 +1,138 @@
+package sim_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/mewspring/ren/pkg/anim"
+	"github.com/mewspring/ren/pkg/entity"
+	"github.com/mewspring/ren/pkg/facing"
+	"github.com/mewspring/ren/pkg/nav"
+	"github.com/mewspring/ren/pkg/sim"
+	"github.com/mewspring/ren/pkg/sim/simtest"
+)
+
+func TestMoveFixedTimestep(t *testing.T) {
+	w := simtest.World(1000, 1000)
+	player := simtest.SpawnPlayer(w, simtest.UnitType("hero"), 500, 500)
+	// Moving for one second of simulation ticks covers the speed of the unit
+	// (up to rounding of the tick duration), independent of frame rate.
+	simtest.Run(w, sim.Input{MoveX: 1}, sim.TPS)
+	if w.Tick != sim.TPS {
+		t.Errorf("tick mismatch; expected %d, got %d", sim.TPS, w.Tick)
+	}
+	if want := 500.0 + simtest.Speed; math.Abs(player.X-want) > 1e-3 || player.Y != 500 {
+		t.Errorf("position mismatch; expected (%v, 500), got (%v, %v)", want, player.X, player.Y)
+	}
+	if player.Dir != facing.East {
+		t.Errorf("facing mismatch; expected %v, got %v", facing.East, player.Dir)
+	}
+	if player.Anim.State != anim.StateRun {
+		t.Errorf("animation state mismatch; expected %v, got %v", anim.StateRun, player.Anim.State)
+	}
+	// Releasing the movement keys returns the unit to stance.
+	w.Update(sim.Input{})
+	if player.Anim.State != anim.StateStance {
+		t.Errorf("animation state mismatch; expected %v, got %v", anim.StateStance, player.Anim.State)
+	}
+}
+
+func TestPause(t *testing.T) {
+	w := simtest.World(1000, 1000)
+	player := simtest.SpawnPlayer(w, simtest.UnitType("hero"), 500, 500)
+	w.Paused = true
+	// Orders are accepted while paused, but not carried out.
+	simtest.Run(w, sim.Input{MoveTo: true, DestX: 600, DestY: 500}, 10)
+	if w.Tick != 0 {
+		t.Errorf("tick mismatch; expected 0, got %d", w.Tick)
+	}
+	if player.X != 500 || player.Y != 500 {
+		t.Errorf("position mismatch; expected (500, 500), got (%v, %v)", player.X, player.Y)
+	}
+	if len(player.Path) == 0 {
+		t.Fatalf("expected path of move order issued while paused")
+	}
+	w.Paused = false
+	simtest.Run(w, sim.Input{}, sim.TPS)
+	if math.Hypot(player.X-600, player.Y-500) > 1e-3 {
+		t.Errorf("position mismatch; expected (600, 500), got (%v, %v)", player.X, player.Y)
+	}
+	if player.Path != nil {
+		t.Errorf("expected arrival at destination, got remaining path %v", player.Path)
+	}
+}
+
+func TestReproducible(t *testing.T) {
+	// Worlds of the same seed and input evolve identically.
+	run := func() uint64 {
+		w := simtest.World(1000, 1000)
+		typ := simtest.UnitType("hero")
+		simtest.SpawnPlayer(w, typ, 500, 500)
+		in := sim.Input{MoveX: 1, MoveY: 1}
+		for i := 0; i < 2*sim.TPS; i++ {
+			in.Attack = i%20 == 0
+			in.TargetX, in.TargetY = 600, 600
+			w.Update(in)
+		}
+		return w.Checksum()
+	}
+	if a, b := run(), run(); a != b {
+		t.Errorf("checksum mismatch; expected %016x, got %016x", a, b)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	w := simtest.World(1000, 1000)
+	typ := simtest.UnitType("hero")
+	player := simtest.SpawnPlayer(w, typ, 500, 500)
+	ally := simtest.Spawn(w, typ, entity.FactionPlayer, 520, 500)
+	dead := simtest.Spawn(w, typ, entity.FactionPlayer, 540, 500)
+	dead.SetDead()
+	monster := simtest.Spawn(w, simtest.UnitType("monster"), entity.FactionMonster, 560, 500)
+	// Only living units of the player faction are selected.
+	sel := &sim.Select{IDs: []int{player.ID, ally.ID, dead.ID, monster.ID}}
+	w.Update(sim.Input{Select: sel})
+	if want := []int{player.ID, ally.ID}; !reflect.DeepEqual(w.Selected, want) {
+		t.Errorf("selection mismatch; expected %v, got %v", want, w.Selected)
+	}
+	// Orders are only issued to selected units.
+	w.Update(sim.Input{MoveTo: true, DestX: 500, DestY: 700})
+	if len(player.Path) == 0 || len(ally.Path) == 0 {
+		t.Errorf("expected move order of selected units")
+	}
+	if len(dead.Path) != 0 || len(monster.Path) != 0 {
+		t.Errorf("expected no move order of dead or hostile units")
+	}
+	// Units killed while selected are no longer ordered.
+	ally.SetDead()
+	w.Update(sim.Input{MoveTo: true, DestX: 700, DestY: 500})
+	if units := w.SelectedUnits(); len(units) != 1 || units[0] != player {
+		t.Errorf("selected units mismatch; expected [%d], got %v", player.ID, units)
+	}
+}
+
+func TestMoveToFailed(t *testing.T) {
+	// The map area is split by a wall; the east side is out of reach.
+	w := simtest.World(1000, 1000)
+	w.Nav = nav.NewGrid(w.Area.BackgroundLayer.Bounds(), 20)
+	for row := 0; row < w.Nav.NRows; row++ {
+		w.Nav.Walkable[row*w.Nav.NCols+30] = false
+	}
+	player := simtest.SpawnPlayer(w, simtest.UnitType("hero"), 200, 500)
+	w.Update(sim.Input{MoveTo: true, DestX: 400, DestY: 500})
+	if len(player.Path) == 0 {
+		t.Fatalf("expected path of move order")
+	}
+	// Failed orders stop the unit, rather than leaving it to follow the path of
+	// the previous order.
+	w.Update(sim.Input{MoveTo: true, DestX: 800, DestY: 500})
+	if player.Path != nil {
+		t.Errorf("expected no path of failed move order, got %v", player.Path)
+	}
+	x, y := player.X, player.Y
+	simtest.Run(w, sim.Input{}, sim.TPS)
+	if player.X != x || player.Y != y {
+		t.Errorf("position mismatch; expected (%v, %v), got (%v, %v)", x, y, player.X, player.Y)
+	}
+}
@@ -0,0 +1,147 @@
+// Package simtest provides fixtures for tests of simulated worlds; map areas,
+// unit types and units, independent of game assets and windows.
+package simtest
+
+import (
+	"image"
+	"time"
+
+	"github.com/mewspring/ren/pkg/anim"
+	"github.com/mewspring/ren/pkg/assets"
+	"github.com/mewspring/ren/pkg/entity"
+	"github.com/mewspring/ren/pkg/sim"
+)
+
+// Stats of units of UnitType.
+const (
+	// Maximum health.
+	MaxHP = 100
+	// Movement speed in pixels per second.
+	Speed = 120
+	// Damage dealt per attack.
+	Damage = 10
+	// Attack range in pixels.
+	Range = 60
+)
+
+// Stats of projectiles of ProjectileType.
+const (
+	// Speed in pixels per second.
+	ProjectileSpeed = 300
+	// Maximum travel distance in pixels.
+	ProjectileRange = 200
+	// Collision radius in pixels.
+	ProjectileRadius = 8
+	// Damage dealt on impact.
+	ProjectileDamage = 20
+	// Radius of area damage in pixels, of projectiles with splash damage.
+	ProjectileSplash = 30
+)
+
+// Area returns a map area of the given size, with blank layers.
+func Area(width, height int) *assets.Area {
+	bounds := image.Rect(0, 0, width, height)
+	return &assets.Area{
+		Name:            "test",
+		BackgroundLayer: image.NewRGBA(bounds),
+		NormalLayer:     image.NewRGBA(bounds),
+		HeightLayer:     image.NewGray(bounds),
+		ASLayer:         image.NewRGBA(bounds),
+	}
+}
+
+// World returns a world of a blank map area of the given size, without units.
+func World(width, height int) *sim.World {
+	return sim.NewWorld(Area(width, height))
+}
+
+// UnitType returns a unit type with the given name, the stats of this package
+// and a sprite sheet of 8x8 pixel frames with stance, run, swing, hit and die
+// animations.
+func UnitType(name string) *entity.Type {
+	const durPerFrame = 50 * time.Millisecond
+	sheet := &anim.Sheet{
+		FrameWidth:  8,
+		FrameHeight: 8,
+		OffsetX:     4,
+		OffsetY:     7,
+		Defs: map[anim.State]anim.Def{
+			anim.StateStance: {FirstFrame: 0, NFrames: 2, Dur: 2 * durPerFrame, AnimType: anim.AnimTypeBackForth},
+			anim.StateRun:    {FirstFrame: 2, NFrames: 4, Dur: 4 * durPerFrame, AnimType: anim.AnimTypeLoop},
+			anim.StateSwing:  {FirstFrame: 6, NFrames: 6, Dur: 6 * durPerFrame, AnimType: anim.AnimTypeOnce, ActiveFrame: 3},
+			anim.StateHit:    {FirstFrame: 12, NFrames: 1, Dur: durPerFrame, AnimType: anim.AnimTypeOnce},
+			anim.StateDie:    {FirstFrame: 13, NFrames: 4, Dur: 4 * durPerFrame, AnimType: anim.AnimTypeOnce},
+		},
+	}
+	return &entity.Type{
+		Name:      name,
+		SheetPath: name + ".png",
+		Sheet:     sheet,
+		Stats: entity.Stats{
+			MaxHP:  MaxHP,
+			Speed:  Speed,
+			Damage: Damage,
+			Range:  Range,
+		},
+	}
+}
+
+// ProjectileType returns a projectile type with the given name, the projectile
+// stats of this package and a sprite sheet of 8x8 pixel frames. Projectiles
+// deal splash damage if splash is set.
+func ProjectileType(name string, splash bool) *entity.ProjectileType {
+	typ := &entity.ProjectileType{
+		Name:      name,
+		SheetPath: name + ".png",
+		Sheet: &anim.Sheet{
+			FrameWidth:  8,
+			FrameHeight: 8,
+			OffsetX:     4,
+			OffsetY:     4,
+			Defs: map[anim.State]anim.Def{
+				anim.StateStance: {FirstFrame: 0, NFrames: 2, Dur: 100 * time.Millisecond, AnimType: anim.AnimTypeLoop},
+			},
+		},
+		Speed:  ProjectileSpeed,
+		Range:  ProjectileRange,
+		Radius: ProjectileRadius,
+		Damage: ProjectileDamage,
+	}
+	if splash {
+		typ.Splash = ProjectileSplash
+	}
+	return typ
+}
+
+// RangedUnitType returns a unit type as returned by UnitType, with a shoot
+// animation firing projectiles of the given type.
+func RangedUnitType(name string, projectile *entity.ProjectileType) *entity.Type {
+	typ := UnitType(name)
+	typ.Sheet.Defs[anim.StateShoot] = anim.Def{FirstFrame: 17, NFrames: 6, Dur: 300 * time.Millisecond, AnimType: anim.AnimTypeOnce, ActiveFrame: 3}
+	typ.Projectile = projectile
+	return typ
+}
+
+// Spawn adds a unit of the given type and faction at (x, y) to the world.
+func Spawn(w *sim.World, typ *entity.Type, faction entity.Faction, x, y float64) *entity.Unit {
+	unit := entity.NewUnit(typ, x, y)
+	unit.Faction = faction
+	return w.Units.Add(unit)
+}
+
+// SpawnPlayer adds the player unit of the given type at (x, y) to the world,
+// and selects it.
+func SpawnPlayer(w *sim.World, typ *entity.Type, x, y float64) *entity.Unit {
+	unit := Spawn(w, typ, entity.FactionPlayer, x, y)
+	w.PlayerID = unit.ID
+	w.Selected = []int{unit.ID}
+	return unit
+}
+
+// Run advances the world by the given number of ticks, with the same player
+// input each tick.
+func Run(w *sim.World, in sim.Input, ticks int) {
+	for i := 0; i < ticks; i++ {
+		w.Update(in)
+	}
+}